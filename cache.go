@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheFormat identifies the layout of cache files. Cache files written in
+// any other layout are discarded when they're opened.
+const cacheFormat = 2
+
+// Cache holds the results of earlier scans on disk, so that drawings that
+// haven't changed since they were last read don't need to be read again.
+//
+// Results are keyed on the absolute path of each file and are only used
+// while the file's size and modification time are unchanged.
+//
+// Cache is threadsafe.
+type Cache struct {
+	path string
+
+	mutex   sync.Mutex
+	entries map[string]cacheEntry
+	dirty   bool
+}
+
+// cacheEntry is a cached scan result.
+type cacheEntry struct {
+	Size                 int64
+	ModTime              time.Time
+	Format               Format
+	Version              DrawingVersion
+	Header               *DrawingHeader     `json:",omitempty"`
+	NoMaintenanceRelease bool               `json:",omitempty"`
+	NoCodepage           bool               `json:",omitempty"`
+	Properties           *DrawingProperties `json:",omitempty"`
+	App                  *DrawingAppInfo    `json:",omitempty"`
+	Reason               Reason             `json:",omitempty"`
+	Err                  string             `json:",omitempty"`
+}
+
+// cacheFile is the on-disk layout of a cache.
+type cacheFile struct {
+	Format  int
+	Entries map[string]cacheEntry
+}
+
+// DefaultCachePath returns the location of the cache file in the user's cache
+// directory.
+func DefaultCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cadscan", "scan-cache.json"), nil
+}
+
+// OpenCache loads the cache stored at path. If there is no cache file yet,
+// or the file can't be understood, an empty cache is returned that will be
+// written to path when it is saved.
+func OpenCache(path string) (*Cache, error) {
+	c := &Cache{
+		path:    path,
+		entries: make(map[string]cacheEntry),
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return c, nil
+	case err != nil:
+		return nil, err
+	}
+
+	var stored cacheFile
+	if json.Unmarshal(data, &stored) != nil || stored.Format != cacheFormat {
+		// Start again rather than trust the contents
+		c.dirty = true
+		return c, nil
+	}
+	if stored.Entries != nil {
+		c.entries = stored.Entries
+	}
+
+	return c, nil
+}
+
+// Save writes the cache to disk if it has changed since it was opened.
+func (c *Cache) Save() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(cacheFile{Format: cacheFormat, Entries: c.entries})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+
+	// Replace the file in one step, so that an interrupted save doesn't
+	// leave a partial cache behind
+	temp := c.path + ".tmp"
+	if err := os.WriteFile(temp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(temp, c.path); err != nil {
+		os.Remove(temp)
+		return err
+	}
+
+	c.dirty = false
+	return nil
+}
+
+// Lookup returns the cached result for file, which must have its Size and
+// ModTime set. It returns false if there is no result for the file or if
+// the file has changed since the result was stored.
+func (c *Cache) Lookup(file File) (File, bool) {
+	c.mutex.Lock()
+	entry, ok := c.entries[cacheKey(file.Path)]
+	c.mutex.Unlock()
+
+	if !ok || entry.Size != file.Size || !entry.ModTime.Equal(file.ModTime) {
+		return File{}, false
+	}
+
+	file.Format = entry.Format
+	file.Version = entry.Version
+	file.Header = entry.Header
+	file.NoMaintenanceRelease = entry.NoMaintenanceRelease
+	file.NoCodepage = entry.NoCodepage
+	file.Properties = entry.Properties
+	file.App = entry.App
+	file.Reason = entry.Reason
+	if entry.Err != "" {
+		file.Err = errors.New(entry.Err)
+	}
+	file.Cached = true
+
+	return file, true
+}
+
+// Store records the result for file, replacing any earlier result.
+//
+// Failures that depend on the circumstances of the scan rather than the
+// file's contents, such as permission errors, are not stored.
+func (c *Cache) Store(file File) {
+	switch file.Reason {
+	case ReasonOpen, ReasonPermission, ReasonTimeout, ReasonRead:
+		return
+	}
+
+	entry := cacheEntry{
+		Size:                 file.Size,
+		ModTime:              file.ModTime,
+		Format:               file.Format,
+		Version:              file.Version,
+		Header:               file.Header,
+		NoMaintenanceRelease: file.NoMaintenanceRelease,
+		NoCodepage:           file.NoCodepage,
+		Properties:           file.Properties,
+		App:                  file.App,
+		Reason:               file.Reason,
+	}
+	if file.Err != nil {
+		entry.Err = file.Err.Error()
+	}
+
+	c.mutex.Lock()
+	c.entries[cacheKey(file.Path)] = entry
+	c.dirty = true
+	c.mutex.Unlock()
+}
+
+// Prune removes the results for files within root that are not in seen,
+// which holds the paths of the files found by a complete scan of root.
+// Results for files that have been deleted or excluded are removed this way.
+// Results for files within the unlisted directories, which the scan failed to
+// list, are kept as the scan couldn't tell whether they still exist.
+func (c *Cache) Prune(root string, seen map[string]bool, unlisted []string) {
+	prefix := dirPrefix(cacheKey(root))
+
+	keep := make(map[string]bool, len(seen))
+	for path := range seen {
+		keep[cacheKey(path)] = true
+	}
+	skip := make([]string, len(unlisted))
+	for i, dir := range unlisted {
+		skip[i] = dirPrefix(cacheKey(dir))
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for key := range c.entries {
+		if !strings.HasPrefix(key, prefix) || keep[key] || hasAnyPrefix(key, skip) {
+			continue
+		}
+		delete(c.entries, key)
+		c.dirty = true
+	}
+}
+
+// dirPrefix returns the prefix shared by the keys of files within dir.
+func dirPrefix(dir string) string {
+	if !strings.HasSuffix(dir, string(filepath.Separator)) {
+		dir += string(filepath.Separator)
+	}
+	return dir
+}
+
+// hasAnyPrefix reports whether s begins with any of prefixes.
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheKey returns the key under which results for path are stored.
+func cacheKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCacheLookup(t *testing.T) {
+	modTime := time.Date(2023, 5, 1, 9, 30, 0, 0, time.UTC)
+	stored := File{
+		Path:    filepath.Join(t.TempDir(), "site.dwg"),
+		Size:    1024,
+		ModTime: modTime,
+		Format:  FormatDWG,
+		Version: "AC1032",
+	}
+
+	cache, err := OpenCache(filepath.Join(t.TempDir(), "cache.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Store(stored)
+
+	tests := []struct {
+		name string
+		file File
+		ok   bool
+	}{
+		{"Unchanged", File{Path: stored.Path, Size: 1024, ModTime: modTime}, true},
+		{"Resized", File{Path: stored.Path, Size: 2048, ModTime: modTime}, false},
+		{"Modified", File{Path: stored.Path, Size: 1024, ModTime: modTime.Add(time.Second)}, false},
+		{"Unknown", File{Path: stored.Path + ".bak", Size: 1024, ModTime: modTime}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := cache.Lookup(tt.file)
+			if ok != tt.ok {
+				t.Fatalf("Lookup() ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if !got.Cached || got.Format != stored.Format || got.Version != stored.Version {
+				t.Errorf("Lookup() = %+v, want a cached copy of %+v", got, stored)
+			}
+		})
+	}
+}
+
+func TestCacheStore(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason Reason
+		stored bool
+	}{
+		{"Drawing", ReasonNone, true},
+		{"Open", ReasonOpen, false},
+		{"Permission", ReasonPermission, false},
+		{"Truncated", ReasonTruncated, true},
+		{"NotDrawing", ReasonNotDrawing, true},
+		{"UnknownHeader", ReasonUnknownHeader, true},
+		{"Timeout", ReasonTimeout, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, err := OpenCache(filepath.Join(t.TempDir(), "cache.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			file := File{Path: "site.dwg", Size: 10, Reason: tt.reason}
+			if tt.reason != ReasonNone {
+				file.Err = errors.New("failed")
+			}
+			cache.Store(file)
+
+			got, ok := cache.Lookup(file)
+			if ok != tt.stored {
+				t.Fatalf("Lookup() ok = %v, want %v", ok, tt.stored)
+			}
+			if ok && (got.Reason != tt.reason || (got.Err == nil) != (file.Err == nil)) {
+				t.Errorf("Lookup() = %+v, want reason %v and error %v", got, tt.reason, file.Err)
+			}
+		})
+	}
+}
+
+func TestCacheSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cadscan", "cache.json")
+	file := File{
+		Path:       filepath.Join(dir, "site.dxf"),
+		Size:       1024,
+		ModTime:    time.Date(2023, 5, 1, 9, 30, 0, 0, time.UTC),
+		Format:     FormatDXF,
+		Version:    "AC1027",
+		Header:     &DrawingHeader{Version: "AC1027", Codepage: 30},
+		NoCodepage: true,
+	}
+
+	cache, err := OpenCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Store(file)
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := reopened.Lookup(File{Path: file.Path, Size: file.Size, ModTime: file.ModTime})
+	if !ok {
+		t.Fatalf("Lookup() found nothing after the cache was saved and reopened")
+	}
+	if !reflect.DeepEqual(got.Header, file.Header) || got.Version != file.Version || got.NoCodepage != file.NoCodepage {
+		t.Errorf("Lookup() = %+v, want %+v", got, file)
+	}
+}
+
+func TestOpenCacheDiscards(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{"Corrupt", "{not json"},
+		{"OldFormat", `{"Format":0,"Entries":{"site.dwg":{"Size":1}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cache.json")
+			if err := os.WriteFile(path, []byte(tt.contents), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cache, err := OpenCache(path)
+			if err != nil {
+				t.Fatalf("OpenCache() error = %v", err)
+			}
+			if len(cache.entries) != 0 {
+				t.Errorf("OpenCache() kept %d entries, want none", len(cache.entries))
+			}
+			if !cache.dirty {
+				t.Errorf("OpenCache() didn't mark the discarded cache to be rewritten")
+			}
+		})
+	}
+}
+
+func TestCachePrune(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "projects")
+	kept := filepath.Join(root, "kept.dwg")
+	deleted := filepath.Join(root, "deleted.dwg")
+	locked := filepath.Join(root, "locked", "plan.dwg")
+	sibling := filepath.Join(dir, "projects2", "other.dwg")
+	outside := filepath.Join(dir, "archive", "old.dwg")
+
+	cache, err := OpenCache(filepath.Join(dir, "cache.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{kept, deleted, locked, sibling, outside} {
+		cache.Store(File{Path: path})
+	}
+
+	cache.Prune(root, map[string]bool{kept: true}, []string{filepath.Join(root, "locked")})
+
+	for _, tt := range []struct {
+		path string
+		kept bool
+	}{
+		{kept, true},
+		{deleted, false},
+		{locked, true},
+		{sibling, true},
+		{outside, true},
+	} {
+		if _, ok := cache.Lookup(File{Path: tt.path}); ok != tt.kept {
+			t.Errorf("after Prune(), Lookup(%s) ok = %v, want %v", tt.path, ok, tt.kept)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import "strconv"
+
+// column describes a column of scan results, as written by the result
+// writers and shown in the scan window.
+type column struct {
+	Title string
+	Width int // Width of the column in the scan window
+	Value func(File) string
+}
+
+// resultColumns are the columns of scan results, in order.
+var resultColumns = []column{
+	{"File", 300, func(f File) string { return f.Path }},
+	{"Format", 60, func(f File) string { return f.Format.String() }},
+	{"Header", 200, func(f File) string { return f.Version.String() }},
+	{"Version", 200, func(f File) string { return f.Version.ReleaseName() }},
+	{"Maint", 50, File.MaintenanceRelease},
+	{"Codepage", 90, File.Codepage},
+	{"Title", 150, property(func(p *DrawingProperties) string { return p.Title })},
+	{"Subject", 150, property(func(p *DrawingProperties) string { return p.Subject })},
+	{"Author", 120, property(func(p *DrawingProperties) string { return p.Author })},
+	{"Keywords", 150, property(func(p *DrawingProperties) string { return p.Keywords })},
+	{"Comments", 200, property(func(p *DrawingProperties) string { return p.Comments })},
+	{"Last Saved By", 120, property(func(p *DrawingProperties) string { return p.LastSavedBy })},
+	{"Revision", 70, property(func(p *DrawingProperties) string { return p.RevisionNumber })},
+	{"Custom", 200, File.CustomProperties},
+	{"Product", 120, File.Product},
+	{"Product Version", 110, File.ProductVersion},
+	{"Trusted", 60, File.Trusted},
+	{"Error", 150, func(f File) string { return f.Reason.String() }},
+}
+
+// exportColumns are the columns of scan results written to exported files.
+// They add the scan root that found each file, so that the results of a scan
+// of several roots can be told apart, and the release ordinal after the
+// release name, which lets drawings be sorted by version in a spreadsheet.
+var exportColumns = insertColumn(
+	insertColumn(resultColumns, "File", column{"Root", 0, func(f File) string { return f.Root }}),
+	"Version", column{"Release", 0, releaseOrdinal})
+
+// releaseOrdinal returns the drawing's release ordinal, or an empty string if
+// its version is unknown.
+func releaseOrdinal(f File) string {
+	if release := f.Version.Release(); release > 0 {
+		return strconv.Itoa(release)
+	}
+	return ""
+}
+
+// insertColumn returns a copy of columns with c inserted after the column
+// with the given title.
+func insertColumn(columns []column, after string, c column) []column {
+	result := make([]column, 0, len(columns)+1)
+	for _, existing := range columns {
+		result = append(result, existing)
+		if existing.Title == after {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// property returns a column value function that selects a drawing property.
+// Files without properties have an empty value.
+func property(fn func(*DrawingProperties) string) func(File) string {
+	return func(f File) string {
+		if f.Properties == nil {
+			return ""
+		}
+		return fn(f.Properties)
+	}
+}
+
+// columnTitles returns the titles of columns.
+func columnTitles(columns []column) []string {
+	titles := make([]string, len(columns))
+	for i, c := range columns {
+		titles[i] = c.Title
+	}
+	return titles
+}
+
+// columnValues returns the values of columns for file.
+func columnValues(columns []column, file File) []string {
+	values := make([]string, len(columns))
+	for i, c := range columns {
+		values[i] = c.Value(file)
+	}
+	return values
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// A command is a headless operation that can be run from the command line.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+// commands is the set of commands understood by cadscan.
+var commands = []command{
+	{name: "scan", summary: "scan directories for drawings and print the results", run: runScan},
+	{name: "previews", summary: "save the preview image of each drawing to a folder", run: runPreviews},
+	{name: "rollup", summary: "total the drawings of each version in each directory", run: runRollup},
+	{name: "rules", summary: "show which files and folders the include and exclude rules skip", run: runRules},
+	{name: "diff", summary: "compare two scan snapshots and list the drawings that changed", run: runDiff},
+}
+
+// runCommand runs the named command with the given arguments and returns
+// its exit status.
+func runCommand(name string, args []string) int {
+	switch name {
+	case "help", "-h", "-help", "--help":
+		usage(os.Stdout)
+		return 0
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(args)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", name)
+	usage(os.Stderr)
+	return 2
+}
+
+// usage writes a summary of the available commands to w.
+func usage(w io.Writer) {
+	program := filepath.Base(os.Args[0])
+	fmt.Fprintf(w, "Usage: %s [command] [arguments]\n\n", program)
+	fmt.Fprintf(w, "Without a command, %s opens the graphical scanner (Windows only).\n\n", program)
+	fmt.Fprintf(w, "Commands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun \"%s <command> -h\" for help with a command.\n", program)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// runDiff compares two snapshots and prints the drawings that were added,
+// removed, upgraded or downgraded between them.
+func runDiff(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	dirs := flags.Bool("dirs", true, "summarize the changes in each directory")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: cadscan diff [flags] <old snapshot> <new snapshot>\n\n")
+		fmt.Fprintf(flags.Output(), "Snapshots are saved by \"cadscan scan -snapshot file\".\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	before, err := loadSnapshot(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	after, err := loadSnapshot(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	for i, doc := range []*jsonDocument{before, after} {
+		if !doc.Complete {
+			fmt.Fprintf(os.Stderr, "Warning: %s records files or directories that couldn't be read.\n", flags.Arg(i))
+		}
+	}
+
+	diff := diffSnapshots(before, after)
+
+	writeDiff(os.Stdout, diff, *dirs)
+
+	var totals [diffKinds]int
+	for _, change := range diff.Changes {
+		totals[change.Kind]++
+	}
+	counts := make([]string, diffKinds)
+	for kind, count := range totals {
+		counts[kind] = fmt.Sprintf("%d %s", count, diffKindNames[kind])
+	}
+	fmt.Fprintf(os.Stderr, "Compared %d files with %d files: %s.\n", countFiles(before), countFiles(after), strings.Join(counts, ", "))
+	if diff.Unreached > 0 {
+		fmt.Fprintf(os.Stderr, "%d files in directories that couldn't be read were not compared.\n", diff.Unreached)
+	}
+
+	return 0
+}
+
+// countFiles returns the number of files recorded in a snapshot, leaving
+// out the directories that couldn't be read.
+func countFiles(doc *jsonDocument) int {
+	n := 0
+	for _, file := range doc.Files {
+		if !file.Dir {
+			n++
+		}
+	}
+	return n
+}
+
+// writeDiff writes the changes in diff to w as aligned columns of text,
+// optionally followed by a summary of the changes in each directory.
+func writeDiff(w io.Writer, diff snapshotDiff, dirs bool) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "Change\tFile\tBefore\tAfter\n")
+	for _, change := range diff.Changes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", diffKindNames[change.Kind], change.Path, change.Before.Header, change.After.Header)
+	}
+	tw.Flush()
+
+	if !dirs || len(diff.Directories) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(diff.Directories))
+	for dir := range diff.Directories {
+		names = append(names, dir)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(tw, "Directory\tAdded\tRemoved\tUpgraded\tDowngraded\n")
+	for _, dir := range names {
+		counts := diff.Directories[dir]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", dir, counts[diffAdded], counts[diffRemoved], counts[diffUpgraded], counts[diffDowngraded])
+	}
+	tw.Flush()
+}
@@ -1,3 +1,5 @@
+//go:build windows
+
 package main
 
 import (
@@ -20,17 +22,22 @@ type Directory struct {
 	ready    chan struct{}
 	children []*Directory
 	once     sync.Once
+	rules    *Rules // Rules that exclude child directories, if any
 }
 
 var _ walk.TreeItem = new(Directory)
 
 // NewDirectory returns a new directory with the given name and parent.
 func NewDirectory(name string, parent *Directory) *Directory {
-	return &Directory{
+	d := &Directory{
 		name:   name,
 		parent: parent,
 		ready:  make(chan struct{}),
 	}
+	if parent != nil {
+		d.rules = parent.rules
+	}
+	return d
 }
 
 // Text returns the text for the tree item.
@@ -83,7 +90,7 @@ func (d *Directory) scan() {
 		files, err := f.Readdir(512)
 		for _, info := range files {
 			name := info.Name()
-			if !info.IsDir() || shouldExclude(name) {
+			if !info.IsDir() || d.excludes(filepath.Join(dirname, name)) {
 				continue
 			}
 			names = append(names, name)
@@ -103,6 +110,20 @@ func (d *Directory) scan() {
 	}
 }
 
+// excludes reports whether the rules exclude the directory at path from a
+// scan of any folder above it. Rules anchored to the scan root don't hide
+// folders from the tree, as the root isn't chosen until the scan starts.
+func (d *Directory) excludes(path string) bool {
+	if d.rules == nil {
+		return false
+	}
+	top := d
+	for top.parent != nil {
+		top = top.parent
+	}
+	return d.rules.SkipDirAnywhere(top.Path(), path)
+}
+
 // Image returns the path of the directory.
 func (d *Directory) Image() interface{} {
 	return d.Path()
@@ -121,12 +142,3 @@ func (d *Directory) Path() string {
 
 	return filepath.Join(elems...)
 }
-
-func shouldExclude(name string) bool {
-	switch name {
-	case "System Volume Information", "pagefile.sys", "swapfile.sys", "$RECYCLE.BIN":
-		return true
-	}
-
-	return false
-}
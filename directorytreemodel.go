@@ -1,3 +1,5 @@
+//go:build windows
+
 package main
 
 import (
@@ -12,8 +14,10 @@ type DirectoryTreeModel struct {
 
 var _ walk.TreeModel = new(DirectoryTreeModel)
 
-// NewDirectoryTreeModel returns a new directory tree model.
-func NewDirectoryTreeModel() (*DirectoryTreeModel, error) {
+// NewDirectoryTreeModel returns a new directory tree model. Directories that
+// rules exclude from a scan of any folder above them are left out of the
+// tree.
+func NewDirectoryTreeModel(rules *Rules) (*DirectoryTreeModel, error) {
 	model := new(DirectoryTreeModel)
 
 	drives, err := walk.DriveNames()
@@ -27,7 +31,9 @@ func NewDirectoryTreeModel() (*DirectoryTreeModel, error) {
 			continue
 		}
 
-		model.roots = append(model.roots, NewDirectory(drive, nil))
+		root := NewDirectory(drive, nil)
+		root.rules = rules
+		model.roots = append(model.roots, root)
 	}
 
 	return model, nil
@@ -0,0 +1,151 @@
+package dwg
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"strings"
+)
+
+// AppInfo identifies the application that last saved a drawing.
+type AppInfo struct {
+	Version         string // Version of the application, such as "24.0.49.0.0"
+	Comment         string // Statement about the application, which is where TrustedDWG is declared
+	ProductName     string // Product name, such as "AutoCAD" or "Civil 3D"
+	BuildVersion    string // Product build, such as "O.49.0.0"
+	RegistryVersion string // Product registry version, such as "24.0"
+	InstallID       string // Product install identifier, such as "ACAD-4101:409"
+}
+
+// productYears maps AutoCAD registry versions to the year of the matching
+// product releases. Vertical products such as Civil 3D share the registry
+// versions of the AutoCAD release they are built on.
+var productYears = map[string]int{
+	"15.0": 2000,
+	"15.1": 2000,
+	"15.6": 2002,
+	"16.0": 2004,
+	"16.1": 2005,
+	"16.2": 2006,
+	"17.0": 2007,
+	"17.1": 2008,
+	"17.2": 2009,
+	"18.0": 2010,
+	"18.1": 2011,
+	"18.2": 2012,
+	"19.0": 2013,
+	"19.1": 2014,
+	"20.0": 2015,
+	"20.1": 2016,
+	"21.0": 2017,
+	"22.0": 2018,
+	"23.0": 2019,
+	"23.1": 2020,
+	"24.0": 2021,
+	"24.1": 2022,
+	"24.2": 2023,
+	"24.3": 2024,
+	"25.0": 2025,
+	"25.1": 2026,
+}
+
+// ProductYear returns the year of the product release that saved the
+// drawing, such as 2024, or 0 if it isn't known.
+func (a *AppInfo) ProductYear() int {
+	return productYears[strings.TrimPrefix(a.RegistryVersion, "R")]
+}
+
+// Trusted reports whether the application declared the drawing to be a
+// TrustedDWG, meaning it was last saved by an Autodesk application or an
+// Autodesk licensed application. Only AutoCAD 2007 and later drawings carry
+// the declaration.
+func (a *AppInfo) Trusted() bool {
+	comment := strings.ToLower(a.Comment)
+	return strings.Contains(comment, "trusted dwg") || strings.Contains(comment, "trusteddwg")
+}
+
+// ReadFileAppInfo attempts to open the file with the given name and return
+// the application that last saved it.
+func ReadFileAppInfo(name string) (*AppInfo, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadAppInfo(f)
+}
+
+// ReadAppInfo returns the application information stored in the AppInfo
+// section of the drawing in r.
+//
+// As with ReadSummaryInfo, only AutoCAD 2004 and later drawings other than
+// AutoCAD 2007 drawings can be read. Other drawings return
+// ErrUnsupportedFormat.
+func ReadAppInfo(r io.ReaderAt) (*AppInfo, error) {
+	s, err := OpenSections(r)
+	if err != nil {
+		return nil, err
+	}
+	return s.AppInfo()
+}
+
+// AppInfo returns the application information stored in the AppInfo section.
+func (s *Sections) AppInfo() (*AppInfo, error) {
+	f := s.f
+	data, err := f.read("AcDb:AppInfo")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseAppInfo(data, f.header)
+}
+
+// parseAppInfo decodes the contents of an AppInfo section.
+func parseAppInfo(data []byte, header *Header) (*AppInfo, error) {
+	d := &sectionDecoder{b: data, codepage: header.Codepage}
+
+	var (
+		info    AppInfo
+		product string
+	)
+	if header.Version.Release() < Version("AC1021").Release() {
+		d.string() // Section name
+		d.uint32()
+		d.string()
+		product = d.string()
+		info.Version = d.string()
+	} else {
+		d.unicode = true
+		d.uint32()
+		d.string() // Section name
+		d.uint32()
+		d.take(16) // Checksum
+		info.Version = d.string()
+		d.take(16)
+		info.Comment = d.string()
+		d.take(16)
+		product = d.string()
+	}
+
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	// The product information is informative, so a malformed element
+	// leaves its fields empty rather than failing.
+	var element struct {
+		Name            string `xml:"name,attr"`
+		BuildVersion    string `xml:"build_version,attr"`
+		RegistryVersion string `xml:"registry_version,attr"`
+		InstallID       string `xml:"install_id_string,attr"`
+	}
+	if xml.Unmarshal([]byte(product), &element) == nil {
+		info.ProductName = element.Name
+		info.BuildVersion = element.BuildVersion
+		info.RegistryVersion = element.RegistryVersion
+		info.InstallID = element.InstallID
+	}
+
+	return &info, nil
+}
@@ -0,0 +1,141 @@
+package dwg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// appInfoSection encodes info as the contents of an AppInfo section in the
+// layout used by drawings of the given version.
+func appInfoSection(version Version, info AppInfo, product string) []byte {
+	le := binary.LittleEndian
+	unicode := version.Release() >= Version("AC1021").Release()
+	var b []byte
+	str := func(s string) { b = appendSectionString(b, s, unicode) }
+
+	if !unicode {
+		str("AppInfoDataList")
+		b = le.AppendUint32(b, 2)
+		str("4001")
+		str(product)
+		str(info.Version)
+		return b
+	}
+
+	b = le.AppendUint32(b, 2)
+	str("AppInfoDataList")
+	b = le.AppendUint32(b, 3)
+	b = append(b, make([]byte, 16)...)
+	str(info.Version)
+	b = append(b, make([]byte, 16)...)
+	str(info.Comment)
+	b = append(b, make([]byte, 16)...)
+	str(product)
+	return b
+}
+
+func TestReadAppInfo(t *testing.T) {
+	const (
+		autocad = `<ProductInformation name ="AutoCAD" build_version="U.152.0.0" registry_version="24.3" install_id_string="ACAD-7101:409" registry_localeID="1033"/>`
+		teigha  = `<ProductInformation name ="Teigha" build_version="0.0" registry_version="3.3" install_id_string="ODA" registry_localeID="1033"/>`
+		trusted = "Autodesk DWG.  This file is a Trusted DWG last saved by an Autodesk application or Autodesk licensed application."
+	)
+
+	tests := []struct {
+		name    string
+		version Version
+		data    []byte
+		want    *AppInfo
+		year    int
+		trusted bool
+	}{
+		{
+			name:    "R2004",
+			version: "AC1018",
+			data:    appInfoSection("AC1018", AppInfo{Version: "2.7.2.0"}, teigha),
+			want:    &AppInfo{Version: "2.7.2.0", ProductName: "Teigha", BuildVersion: "0.0", RegistryVersion: "3.3", InstallID: "ODA"},
+		},
+		{
+			name:    "R2018",
+			version: "AC1032",
+			data:    appInfoSection("AC1032", AppInfo{Version: "24.3.152.0.0", Comment: trusted}, autocad),
+			want:    &AppInfo{Version: "24.3.152.0.0", Comment: trusted, ProductName: "AutoCAD", BuildVersion: "U.152.0.0", RegistryVersion: "24.3", InstallID: "ACAD-7101:409"},
+			year:    2024,
+			trusted: true,
+		},
+		{
+			name:    "MalformedProduct",
+			version: "AC1027",
+			data:    appInfoSection("AC1027", AppInfo{Version: "1.0"}, "<Product"),
+			want:    &AppInfo{Version: "1.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drawing := sectionDrawing(string(tt.version), Header{}, testSection{name: "AcDb:AppInfo", data: tt.data})
+			got, err := ReadAppInfo(bytes.NewReader(drawing))
+			if err != nil {
+				t.Fatalf("ReadAppInfo() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ReadAppInfo() = %+v, want %+v", got, tt.want)
+			}
+			if year := got.ProductYear(); year != tt.year {
+				t.Errorf("ProductYear() = %d, want %d", year, tt.year)
+			}
+			if trusted := got.Trusted(); trusted != tt.trusted {
+				t.Errorf("Trusted() = %t, want %t", trusted, tt.trusted)
+			}
+		})
+	}
+}
+
+func TestReadAppInfoTruncated(t *testing.T) {
+	data := appInfoSection("AC1032", AppInfo{Version: "24.3"}, "")[:30]
+	drawing := sectionDrawing("AC1032", Header{}, testSection{name: "AcDb:AppInfo", data: data})
+	if _, err := ReadAppInfo(bytes.NewReader(drawing)); err != ErrCorrupt {
+		t.Fatalf("ReadAppInfo() error = %v, want %v", err, ErrCorrupt)
+	}
+}
+
+func TestAppFormat(t *testing.T) {
+	tests := []struct {
+		app  int
+		want Version
+	}{
+		{0x19, "AC1018"},
+		{0x1F, "AC1027"},
+		{0x21, "AC1032"},
+		{0x00, ""},
+		{0x30, ""},
+	}
+
+	for _, tt := range tests {
+		if got := (&Header{AppVersion: tt.app}).AppFormat(); got != tt.want {
+			t.Errorf("AppFormat() with app version %#x = %q, want %q", tt.app, got, tt.want)
+		}
+	}
+}
+
+func TestProductYear(t *testing.T) {
+	tests := []struct {
+		registry string
+		want     int
+	}{
+		{"15.0", 2000},
+		{"15.6", 2002},
+		{"R22.0", 2018},
+		{"24.3", 2024},
+		{"15.2", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		if got := (&AppInfo{RegistryVersion: tt.registry}).ProductYear(); got != tt.want {
+			t.Errorf("ProductYear() with registry version %q = %d, want %d", tt.registry, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package dwg
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Codepage identifies the character encoding of text stored in a drawing.
+//
+// The value is the DWGCODEPAGE index stored in the drawing header, not a
+// Windows code page number.
+type Codepage int
+
+// codepageNames are the $DWGCODEPAGE names of each codepage index.
+var codepageNames = [...]string{
+	"UNDEFINED", "ASCII", "ISO8859-1", "ISO8859-2", "ISO8859-3",
+	"ISO8859-4", "ISO8859-5", "ISO8859-6", "ISO8859-7", "ISO8859-8",
+	"ISO8859-9", "DOS437", "DOS850", "DOS852", "DOS855", "DOS857",
+	"DOS860", "DOS861", "DOS863", "DOS864", "DOS865", "DOS869", "DOS932",
+	"MACINTOSH", "BIG5", "KSC5601", "JOHAB", "DOS866", "ANSI_1250",
+	"ANSI_1251", "ANSI_1252", "GB2312", "ANSI_1253", "ANSI_1254",
+	"ANSI_1255", "ANSI_1256", "ANSI_1257", "ANSI_874", "ANSI_932",
+	"ANSI_936", "ANSI_949", "ANSI_950", "ANSI_1361", "ANSI_1200",
+	"ANSI_1258",
+}
+
+// ParseCodepage returns the codepage with the given $DWGCODEPAGE name. It
+// returns false if the name is not recognized.
+func ParseCodepage(name string) (Codepage, bool) {
+	for i, n := range codepageNames {
+		if strings.EqualFold(n, name) {
+			return Codepage(i), true
+		}
+	}
+	return 0, false
+}
+
+// String returns the $DWGCODEPAGE name of the codepage.
+func (c Codepage) String() string {
+	if c >= 0 && int(c) < len(codepageNames) {
+		return codepageNames[c]
+	}
+	return "UNKNOWN"
+}
+
+// charmaps are the single byte encodings of codepages that can be decoded.
+var charmaps = map[Codepage]*charmap.Charmap{
+	2:  charmap.ISO8859_1,
+	3:  charmap.ISO8859_2,
+	4:  charmap.ISO8859_3,
+	5:  charmap.ISO8859_4,
+	6:  charmap.ISO8859_5,
+	7:  charmap.ISO8859_6,
+	8:  charmap.ISO8859_7,
+	9:  charmap.ISO8859_8,
+	10: charmap.ISO8859_9,
+	11: charmap.CodePage437,
+	12: charmap.CodePage850,
+	13: charmap.CodePage852,
+	14: charmap.CodePage855,
+	16: charmap.CodePage860,
+	18: charmap.CodePage863,
+	20: charmap.CodePage865,
+	23: charmap.Macintosh,
+	27: charmap.CodePage866,
+	28: charmap.Windows1250,
+	29: charmap.Windows1251,
+	30: charmap.Windows1252,
+	32: charmap.Windows1253,
+	33: charmap.Windows1254,
+	34: charmap.Windows1255,
+	35: charmap.Windows1256,
+	36: charmap.Windows1257,
+	37: charmap.Windows874,
+	44: charmap.Windows1258,
+}
+
+// Decode converts text stored in the codepage to a UTF-8 string.
+//
+// Multi-byte and unknown codepages are not converted. Text in them that is
+// not already valid UTF-8 is treated as Windows-1252, the most common
+// codepage for drawings.
+func (c Codepage) Decode(b []byte) string {
+	enc, ok := charmaps[c]
+	if !ok {
+		if utf8.Valid(b) {
+			return string(b)
+		}
+		enc = charmap.Windows1252
+	}
+
+	s, err := enc.NewDecoder().Bytes(b)
+	if err != nil {
+		return string(b)
+	}
+	return string(s)
+}
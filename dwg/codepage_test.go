@@ -0,0 +1,30 @@
+package dwg
+
+import "testing"
+
+func TestCodepageDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		codepage Codepage
+		text     string
+		want     string
+	}{
+		{"ANSI_1252", 30, "Caf\xe9", "Café"},
+		{"ANSI_1251", 29, "\xcf\xeb\xe0\xed", "План"},
+		{"DOS437", 11, "\x82t\x82", "été"},
+		{"Unmapped UTF-8", 0, "Café", "Café"},
+		{"Unmapped", 0, "Caf\xe9", "Café"},
+		{"US-ASCII", 1, "Caf\xe9", "Café"},
+		{"DOS932", 22, "Caf\xe9", "Café"},
+		{"ANSI_949", 40, "Caf\xe9", "Café"},
+		{"Unknown", 1000, "Caf\xe9", "Café"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.codepage.Decode([]byte(tt.text)); got != tt.want {
+				t.Errorf("Decode(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
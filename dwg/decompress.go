@@ -0,0 +1,185 @@
+package dwg
+
+// decompress expands src, which was compressed with the LZ77 variant used by
+// AutoCAD 2004 and later drawings, into at most size bytes.
+//
+// The compressed stream starts with a run of literal bytes and continues
+// with opcodes that each copy a run of previously decompressed bytes and
+// then another run of literals.
+func decompress(src []byte, size int) ([]byte, error) {
+	z := &lz77{src: src, dst: make([]byte, 0, size), size: size}
+
+	length, opcode, err := z.literalLength()
+	if err != nil {
+		return nil, err
+	}
+	if err := z.literal(length); err != nil {
+		return nil, err
+	}
+
+	for {
+		if opcode == 0 {
+			if z.pos >= len(z.src) {
+				return z.dst, nil
+			}
+			if opcode, err = z.byte(); err != nil {
+				return nil, err
+			}
+		}
+
+		var count, offset int
+
+		switch {
+		case opcode == 0x11:
+			return z.dst, nil
+		case opcode >= 0x40:
+			count = int(opcode>>4) - 1
+			b, err := z.byte()
+			if err != nil {
+				return nil, err
+			}
+			offset = int(b)<<2 | int(opcode&0x0C)>>2
+			length = int(opcode & 0x03)
+		case opcode >= 0x21:
+			count = int(opcode) - 0x1E
+			offset, length, err = z.twoByteOffset()
+		case opcode == 0x20:
+			count, err = z.longCount()
+			count += 0x21
+			if err == nil {
+				offset, length, err = z.twoByteOffset()
+			}
+		case opcode >= 0x12:
+			count = int(opcode&0x0F) + 2
+			offset, length, err = z.twoByteOffset()
+			offset += 0x3FFF
+		case opcode == 0x10:
+			count, err = z.longCount()
+			count += 9
+			if err == nil {
+				offset, length, err = z.twoByteOffset()
+				offset += 0x3FFF
+			}
+		default:
+			return nil, ErrCorrupt
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// A literal length of zero means the literal length, or the next
+		// opcode, follows
+		if length != 0 {
+			opcode = 0
+		} else if length, opcode, err = z.literalLength(); err != nil {
+			return nil, err
+		}
+
+		if err := z.copy(offset, count); err != nil {
+			return nil, err
+		}
+		if err := z.literal(length); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// lz77 holds the state of a decompression.
+type lz77 struct {
+	src  []byte
+	pos  int
+	dst  []byte
+	size int
+}
+
+func (z *lz77) byte() (byte, error) {
+	if z.pos >= len(z.src) {
+		return 0, ErrCorrupt
+	}
+	b := z.src[z.pos]
+	z.pos++
+	return b, nil
+}
+
+// literalLength reads the length of a run of literal bytes. If the next byte
+// is an opcode instead, the length is zero and the opcode is returned.
+func (z *lz77) literalLength() (length int, opcode byte, err error) {
+	b, err := z.byte()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	switch {
+	case b >= 0x01 && b <= 0x0F:
+		return int(b) + 3, 0, nil
+	case b == 0:
+		total := 0x0F
+		for {
+			if b, err = z.byte(); err != nil {
+				return 0, 0, err
+			}
+			if b != 0 {
+				return total + int(b) + 3, 0, nil
+			}
+			total += 0xFF
+		}
+	default:
+		return 0, b, nil
+	}
+}
+
+// longCount reads a variable length byte count.
+func (z *lz77) longCount() (int, error) {
+	b, err := z.byte()
+	if err != nil || b != 0 {
+		return int(b), err
+	}
+
+	total := 0xFF
+	for {
+		if b, err = z.byte(); err != nil {
+			return 0, err
+		}
+		if b != 0 {
+			return total + int(b), nil
+		}
+		total += 0xFF
+	}
+}
+
+// twoByteOffset reads a back-reference offset, the low bits of which hold
+// the length of the literal run that follows.
+func (z *lz77) twoByteOffset() (offset, length int, err error) {
+	first, err := z.byte()
+	if err != nil {
+		return 0, 0, err
+	}
+	second, err := z.byte()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(first>>2) | int(second)<<6, int(first & 0x03), nil
+}
+
+// literal copies length bytes from the source to the output.
+func (z *lz77) literal(length int) error {
+	if z.pos+length > len(z.src) || len(z.dst)+length > z.size {
+		return ErrCorrupt
+	}
+	z.dst = append(z.dst, z.src[z.pos:z.pos+length]...)
+	z.pos += length
+	return nil
+}
+
+// copy appends count bytes starting offset+1 bytes back in the output. The
+// source and destination may overlap, so bytes are copied one at a time.
+func (z *lz77) copy(offset, count int) error {
+	start := len(z.dst) - offset - 1
+	if start < 0 || len(z.dst)+count > z.size {
+		return ErrCorrupt
+	}
+	for i := 0; i < count; i++ {
+		z.dst = append(z.dst, z.dst[start+i])
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package dwg
+
+import (
+	"bytes"
+	"testing"
+)
+
+// compressLiterals encodes data as a compressed stream made up of a single
+// literal run. It is the simplest valid encoding of any data at least four
+// bytes long.
+func compressLiterals(data []byte) []byte {
+	var b []byte
+	switch n := len(data); {
+	case n < 4:
+		panic("literal runs must be at least four bytes long")
+	case n <= 0x12:
+		b = append(b, byte(n-3))
+	default:
+		b = append(b, 0)
+		n -= 0x12
+		for n > 0xFF {
+			b = append(b, 0)
+			n -= 0xFF
+		}
+		b = append(b, byte(n))
+	}
+	b = append(b, data...)
+	return append(b, 0x11)
+}
+
+func TestDecompress(t *testing.T) {
+	long := bytes.Repeat([]byte("0123456789"), 100)
+
+	tests := []struct {
+		name string
+		src  []byte
+		size int
+		want []byte
+		err  error
+	}{
+		{
+			name: "ShortLiteral",
+			src:  compressLiterals([]byte("abcd")),
+			size: 4,
+			want: []byte("abcd"),
+		},
+		{
+			name: "LongLiteral",
+			src:  compressLiterals(long),
+			size: len(long),
+			want: long,
+		},
+		{
+			name: "BoundaryLiteral",
+			src:  compressLiterals(long[:0x12+0xFF]),
+			size: 0x12 + 0xFF,
+			want: long[:0x12+0xFF],
+		},
+		{
+			// Copy four bytes from four back, then stop
+			name: "ShortCopy",
+			src:  []byte{0x01, 'a', 'b', 'c', 'd', 0x5C, 0x00, 0x11},
+			size: 8,
+			want: []byte("abcdabcd"),
+		},
+		{
+			// Copy five bytes from one back, overlapping the output, with
+			// a two byte literal packed into the opcode
+			name: "OverlappingCopy",
+			src:  []byte{0x01, 'a', 'b', 'c', 'd', 0x62, 0x00, 'x', 'y', 0x11},
+			size: 11,
+			want: []byte("abcddddddxy"),
+		},
+		{
+			// Copy 0x21-0x1E = 3 bytes from four back using a two byte
+			// offset, followed by a one byte literal
+			name: "TwoByteOffset",
+			src:  []byte{0x01, 'w', 'x', 'y', 'z', 0x21, 0x0D, 0x00, '!', 0x11},
+			size: 8,
+			want: []byte("wxyzwxy!"),
+		},
+		{
+			name: "EndOfInput",
+			src:  []byte{0x01, 'a', 'b', 'c', 'd'},
+			size: 4,
+			want: []byte("abcd"),
+		},
+		{
+			name: "CopyBeforeStart",
+			src:  []byte{0x01, 'a', 'b', 'c', 'd', 0x5C, 0x10, 0x11},
+			size: 8,
+			err:  ErrCorrupt,
+		},
+		{
+			name: "Overflow",
+			src:  compressLiterals([]byte("abcdef")),
+			size: 4,
+			err:  ErrCorrupt,
+		},
+		{
+			name: "Truncated",
+			src:  []byte{0x05, 'a', 'b'},
+			size: 8,
+			err:  ErrCorrupt,
+		},
+		{
+			name: "BadOpcode",
+			src:  []byte{0x01, 'a', 'b', 'c', 'd', 0x05},
+			size: 8,
+			err:  ErrCorrupt,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decompress(tt.src, tt.size)
+			if err != tt.err {
+				t.Fatalf("decompress() error = %v, want %v", err, tt.err)
+			}
+			if err == nil && !bytes.Equal(got, tt.want) {
+				t.Fatalf("decompress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package dwg
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+// Security holds the security flags of an R2004 or later drawing.
+type Security uint32
+
+// Security flags stored in R2004 and later drawing headers.
+const (
+	EncryptData       Security = 0x1 // Object data is encrypted
+	EncryptProperties Security = 0x2 // Drawing properties are encrypted
+	SignData          Security = 0x4 // The drawing is digitally signed
+	AddTimestamp      Security = 0x8 // The signature includes a timestamp
+)
+
+// Header holds the fields of a drawing's fixed file header.
+//
+// Drawings older than Release 13 carry only their version. Release 13 to
+// AutoCAD 2000 drawings add the maintenance release, codepage and preview
+// offset. AutoCAD 2004 and later drawings also record the application that
+// last saved them, their security flags and the locations of their
+// properties sections.
+type Header struct {
+	Version            Version
+	MaintenanceRelease int      // ACADMAINTVER of the release that saved the drawing
+	Codepage           Codepage // DWGCODEPAGE of text in the drawing
+	PreviewOffset      int64    // Offset of the preview image, or 0 if there is none
+
+	// Fields present in AutoCAD 2004 and later drawings
+	AppVersion         int      // Internal version of the application that last saved the drawing
+	AppMaintenance     int      // Maintenance release of the application that last saved the drawing
+	Security           Security // Security flags
+	SummaryInfoAddress int64    // Address of the SummaryInfo section data
+	VBAProjectAddress  int64    // Address of the VBA project data, or 0 if there is none
+	AppInfoAddress     int64    // Address of the AppInfo section data
+}
+
+// headerLength returns the number of bytes of the fixed file header that are
+// decoded for drawings of version v.
+func headerLength(v Version) int {
+	switch {
+	case v.Release() >= Version("AC1018").Release():
+		return 0x30
+	case v.Release() >= Version("AC1012").Release():
+		return 0x15
+	default:
+		return 6
+	}
+}
+
+// ReadFileHeader attempts to open the file with the given name and decode
+// its fixed file header.
+func ReadFileHeader(name string) (*Header, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadHeader(f)
+}
+
+// ReadHeader decodes the fixed file header at the start of r.
+func ReadHeader(r io.ReaderAt) (*Header, error) {
+	version, err := ReadVersion(r)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Header{Version: version}
+
+	length := headerLength(version)
+	if length <= 6 {
+		return h, nil
+	}
+
+	b := make([]byte, length)
+	n, err := r.ReadAt(b, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if n < length {
+		return nil, ErrTruncated
+	}
+
+	le := binary.LittleEndian
+
+	h.MaintenanceRelease = int(b[0x0B])
+	h.PreviewOffset = int64(le.Uint32(b[0x0D:]))
+	h.Codepage = Codepage(le.Uint16(b[0x13:]))
+
+	if length < 0x30 {
+		return h, nil
+	}
+
+	h.AppVersion = int(b[0x11])
+	h.AppMaintenance = int(b[0x12])
+	h.Security = Security(le.Uint32(b[0x18:]))
+	h.SummaryInfoAddress = int64(le.Uint32(b[0x20:]))
+	h.VBAProjectAddress = int64(le.Uint32(b[0x24:]))
+	h.AppInfoAddress = int64(le.Uint32(b[0x2C:]))
+
+	return h, nil
+}
+
+// AppFormat returns the drawing version that the application that last saved
+// the drawing writes natively. It differs from the drawing's own version
+// when a drawing is saved in an older format. An empty version is returned
+// if the header doesn't record a known application version.
+func (h *Header) AppFormat() Version {
+	switch h.AppVersion {
+	case 0x17:
+		return "AC1015"
+	case 0x19:
+		return "AC1018"
+	case 0x1B:
+		return "AC1021"
+	case 0x1D:
+		return "AC1024"
+	case 0x1F:
+		return "AC1027"
+	case 0x21:
+		return "AC1032"
+	default:
+		return ""
+	}
+}
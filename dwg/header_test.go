@@ -0,0 +1,134 @@
+package dwg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// r13Header returns a synthetic Release 13 to AutoCAD 2000 file header.
+func r13Header(version string, maint byte, preview uint32, codepage uint16) []byte {
+	b := make([]byte, 0x19)
+	copy(b, version)
+	b[0x0B] = maint
+	b[0x0C] = 1
+	binary.LittleEndian.PutUint32(b[0x0D:], preview)
+	binary.LittleEndian.PutUint16(b[0x13:], codepage)
+	return b
+}
+
+// r2004Header returns a synthetic AutoCAD 2004 or later file header.
+func r2004Header(version string, h Header) []byte {
+	b := make([]byte, 0x100)
+	copy(b, version)
+	le := binary.LittleEndian
+	b[0x0B] = byte(h.MaintenanceRelease)
+	b[0x0C] = 3
+	le.PutUint32(b[0x0D:], uint32(h.PreviewOffset))
+	b[0x11] = byte(h.AppVersion)
+	b[0x12] = byte(h.AppMaintenance)
+	le.PutUint16(b[0x13:], uint16(h.Codepage))
+	le.PutUint32(b[0x18:], uint32(h.Security))
+	le.PutUint32(b[0x20:], uint32(h.SummaryInfoAddress))
+	le.PutUint32(b[0x24:], uint32(h.VBAProjectAddress))
+	le.PutUint32(b[0x28:], 0x80)
+	le.PutUint32(b[0x2C:], uint32(h.AppInfoAddress))
+	return b
+}
+
+func TestReadHeader(t *testing.T) {
+	r2018 := Header{
+		Version:            "AC1032",
+		MaintenanceRelease: 4,
+		Codepage:           30,
+		PreviewOffset:      0x1C0,
+		AppVersion:         0x21,
+		AppMaintenance:     0x2C,
+		Security:           EncryptProperties,
+		SummaryInfoAddress: 0x20,
+		VBAProjectAddress:  0,
+		AppInfoAddress:     0x3A0,
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want *Header
+		err  error
+	}{
+		{
+			name: "R12",
+			data: []byte("AC1009\x00\x00\x00\x00\x00\x00\x00"),
+			want: &Header{Version: "AC1009"},
+		},
+		{
+			name: "R14",
+			data: r13Header("AC1014", 0, 0x3A5, 30),
+			want: &Header{Version: "AC1014", Codepage: 30, PreviewOffset: 0x3A5},
+		},
+		{
+			name: "R2000",
+			data: r13Header("AC1015", 6, 0x1A0, 29),
+			want: &Header{Version: "AC1015", MaintenanceRelease: 6, Codepage: 29, PreviewOffset: 0x1A0},
+		},
+		{
+			name: "R2018",
+			data: r2004Header("AC1032", r2018),
+			want: &r2018,
+		},
+		{
+			name: "R2004Truncated",
+			data: r2004Header("AC1018", Header{})[:0x20],
+			err:  ErrTruncated,
+		},
+		{
+			name: "R2000Truncated",
+			data: []byte("AC1015\x00\x00"),
+			err:  ErrTruncated,
+		},
+		{
+			name: "NotDrawing",
+			data: []byte("not a drawing at all"),
+			err:  ErrInvalidDrawing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadHeader(bytes.NewReader(tt.data))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ReadHeader() error = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ReadHeader() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodepage(t *testing.T) {
+	tests := []struct {
+		codepage Codepage
+		name     string
+	}{
+		{0, "UNDEFINED"},
+		{30, "ANSI_1252"},
+		{44, "ANSI_1258"},
+		{45, "UNKNOWN"},
+		{-1, "UNKNOWN"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.codepage.String(); got != tt.name {
+			t.Errorf("Codepage(%d).String() = %q, want %q", tt.codepage, got, tt.name)
+		}
+		if tt.name == "UNKNOWN" {
+			continue
+		}
+		if got, ok := ParseCodepage(tt.name); !ok || got != tt.codepage {
+			t.Errorf("ParseCodepage(%q) = %d, %t, want %d", tt.name, got, ok, tt.codepage)
+		}
+	}
+}
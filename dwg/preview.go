@@ -0,0 +1,228 @@
+package dwg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+
+	"golang.org/x/image/bmp"
+)
+
+var (
+	// ErrNoPreview is returned when a drawing does not contain a preview
+	// image.
+	ErrNoPreview = errors.New("drawing has no preview image")
+
+	// ErrInvalidPreview is returned when a drawing's preview data is
+	// malformed.
+	ErrInvalidPreview = errors.New("invalid preview image data")
+
+	// ErrUnsupportedPreview is returned when a preview image is stored in a
+	// format that can't be decoded.
+	ErrUnsupportedPreview = errors.New("unsupported preview image format")
+)
+
+// maxPreviewSize limits the memory used to read a preview image. Previews
+// saved by AutoCAD are far smaller.
+const maxPreviewSize = 16 << 20
+
+// previewSentinel marks the start of the preview data in a drawing.
+var previewSentinel = []byte{
+	0x1F, 0x25, 0x6D, 0x07, 0xD4, 0x36, 0x28, 0x28,
+	0x9D, 0x57, 0xCA, 0x3F, 0x9D, 0x44, 0x10, 0x2B,
+}
+
+// PreviewFormat identifies the encoding of a preview image.
+type PreviewFormat int
+
+// Preview image formats. The values match the entry codes used in drawings.
+const (
+	PreviewBMP PreviewFormat = 2 // Device independent bitmap, without a file header
+	PreviewWMF PreviewFormat = 3 // Windows metafile
+	PreviewPNG PreviewFormat = 6 // PNG image, AutoCAD 2013 and later
+)
+
+// String returns the name of the format.
+func (f PreviewFormat) String() string {
+	switch f {
+	case PreviewBMP:
+		return "BMP"
+	case PreviewWMF:
+		return "WMF"
+	case PreviewPNG:
+		return "PNG"
+	default:
+		return fmt.Sprintf("PreviewFormat(%d)", int(f))
+	}
+}
+
+// Ext returns the file name extension for previews of the format.
+func (f PreviewFormat) Ext() string {
+	switch f {
+	case PreviewBMP:
+		return ".bmp"
+	case PreviewWMF:
+		return ".wmf"
+	case PreviewPNG:
+		return ".png"
+	default:
+		return ".bin"
+	}
+}
+
+// Preview is a thumbnail image embedded in a drawing.
+type Preview struct {
+	Format PreviewFormat
+	Data   []byte // The image data, exactly as stored in the drawing
+}
+
+// ReadFilePreview attempts to open the file with the given name and return
+// its preview image.
+func ReadFilePreview(name string) (*Preview, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadPreview(f)
+}
+
+// ReadPreview returns the preview image of the drawing in r.
+//
+// When a drawing holds more than one image the PNG is preferred, followed by
+// the bitmap and then the metafile. Drawings older than Release 13 do not
+// have previews.
+func ReadPreview(r io.ReaderAt) (*Preview, error) {
+	header, err := ReadHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if header.PreviewOffset == 0 {
+		return nil, ErrNoPreview
+	}
+
+	// Sentinel, overall size and entry count
+	var b [21]byte
+	if err := readAt(r, b[:], header.PreviewOffset); err != nil {
+		return nil, previewError(err)
+	}
+	if !bytes.Equal(b[:16], previewSentinel) {
+		return nil, ErrInvalidPreview
+	}
+
+	count := int(b[20])
+	entries := make([]byte, count*9)
+	if err := readAt(r, entries, header.PreviewOffset+21); err != nil {
+		return nil, previewError(err)
+	}
+
+	var (
+		best       PreviewFormat
+		start, end int64
+	)
+	for i := 0; i < count; i++ {
+		entry := entries[i*9:]
+		format := PreviewFormat(entry[0])
+		if previewRank(format) <= previewRank(best) {
+			continue
+		}
+		best = format
+		start = int64(binary.LittleEndian.Uint32(entry[1:]))
+		end = start + int64(binary.LittleEndian.Uint32(entry[5:]))
+	}
+
+	if best == 0 || start == end {
+		return nil, ErrNoPreview
+	}
+	if end-start > maxPreviewSize {
+		return nil, ErrInvalidPreview
+	}
+
+	data := make([]byte, end-start)
+	if err := readAt(r, data, start); err != nil {
+		return nil, previewError(err)
+	}
+
+	return &Preview{Format: best, Data: data}, nil
+}
+
+// previewRank returns the order of preference for preview formats. Higher
+// ranks are preferred. Unsupported formats have a rank of 0.
+func previewRank(f PreviewFormat) int {
+	switch f {
+	case PreviewPNG:
+		return 3
+	case PreviewBMP:
+		return 2
+	case PreviewWMF:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// previewError translates a read error encountered within the preview data.
+// Preview data that runs past the end of the file is invalid.
+func previewError(err error) error {
+	if err == ErrCorrupt {
+		return ErrInvalidPreview
+	}
+	return err
+}
+
+// Image decodes the preview image.
+//
+// Metafile previews cannot be decoded and return ErrUnsupportedPreview.
+func (p *Preview) Image() (image.Image, error) {
+	switch p.Format {
+	case PreviewPNG:
+		return png.Decode(bytes.NewReader(p.Data))
+	case PreviewBMP:
+		file, err := p.File()
+		if err != nil {
+			return nil, err
+		}
+		return bmp.Decode(bytes.NewReader(file))
+	default:
+		return nil, ErrUnsupportedPreview
+	}
+}
+
+// File returns the preview as the contents of a standalone image file with
+// the extension returned by p.Format.Ext().
+//
+// Bitmaps are stored in drawings without a file header, so one is added.
+// Other formats are returned as stored.
+func (p *Preview) File() ([]byte, error) {
+	if p.Format != PreviewBMP {
+		return p.Data, nil
+	}
+
+	// The bitmap starts with a BITMAPINFOHEADER, which is followed by the
+	// color table and then the pixels.
+	if len(p.Data) < 40 {
+		return nil, ErrInvalidPreview
+	}
+	le := binary.LittleEndian
+	infoSize := le.Uint32(p.Data[0:])
+	bitCount := le.Uint16(p.Data[14:])
+	colors := le.Uint32(p.Data[32:])
+	if colors == 0 && bitCount <= 8 {
+		colors = 1 << bitCount
+	}
+	offset := 14 + infoSize + colors*4
+
+	file := make([]byte, 14+len(p.Data))
+	file[0], file[1] = 'B', 'M'
+	le.PutUint32(file[2:], uint32(len(file)))
+	le.PutUint32(file[10:], offset)
+	copy(file[14:], p.Data)
+
+	return file, nil
+}
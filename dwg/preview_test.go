@@ -0,0 +1,221 @@
+package dwg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"testing"
+)
+
+// previewEntry is an image to be stored in a synthetic drawing's preview.
+type previewEntry struct {
+	format PreviewFormat
+	data   []byte
+}
+
+// drawingWithPreview returns a synthetic AutoCAD 2000 drawing with the given
+// preview entries.
+func drawingWithPreview(entries ...previewEntry) []byte {
+	const offset = 0x40
+
+	b := r13Header("AC1015", 0, offset, 30)
+	b = append(b, make([]byte, offset-len(b))...)
+	b = append(b, previewSentinel...)
+	b = binary.LittleEndian.AppendUint32(b, 0) // Overall size, unused
+	b = append(b, byte(len(entries)))
+
+	start := len(b) + len(entries)*9
+	for _, entry := range entries {
+		b = append(b, byte(entry.format))
+		b = binary.LittleEndian.AppendUint32(b, uint32(start))
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(entry.data)))
+		start += len(entry.data)
+	}
+	for _, entry := range entries {
+		b = append(b, entry.data...)
+	}
+
+	return b
+}
+
+// oversizedPreview returns a synthetic drawing whose preview claims to be
+// almost 4GB in size.
+func oversizedPreview() []byte {
+	b := drawingWithPreview(previewEntry{PreviewPNG, []byte("tiny")})
+	binary.LittleEndian.PutUint32(b[0x40+21+5:], 0xFFFFFFF0)
+	return b
+}
+
+// testBitmap returns a 2x2 24-bit bitmap without a file header. Rows are
+// stored bottom-up and padded to four bytes.
+func testBitmap() []byte {
+	var b []byte
+	le := binary.LittleEndian
+	b = le.AppendUint32(b, 40) // Header size
+	b = le.AppendUint32(b, 2)  // Width
+	b = le.AppendUint32(b, 2)  // Height
+	b = le.AppendUint16(b, 1)  // Planes
+	b = le.AppendUint16(b, 24) // Bits per pixel
+	b = le.AppendUint32(b, 0)  // Compression
+	b = le.AppendUint32(b, 16) // Image size
+	b = append(b, make([]byte, 16)...)
+	b = append(b, 0, 0, 255, 0, 255, 0, 0, 0) // Bottom row: red, green
+	b = append(b, 255, 0, 0, 255, 255, 255, 0, 0)
+	return b
+}
+
+// testPNG returns a 1x1 white PNG.
+func testPNG(t *testing.T) []byte {
+	img := image.NewGray(image.Rect(0, 0, 1, 1))
+	img.Set(0, 0, color.White)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadPreview(t *testing.T) {
+	bitmap := testBitmap()
+	pngData := testPNG(t)
+	metafile := []byte("not really a metafile")
+	headerData := []byte{1, 2, 3}
+
+	tests := []struct {
+		name    string
+		drawing []byte
+		format  PreviewFormat
+		data    []byte
+		err     error
+	}{
+		{
+			name:    "Bitmap",
+			drawing: drawingWithPreview(previewEntry{1, headerData}, previewEntry{PreviewBMP, bitmap}),
+			format:  PreviewBMP,
+			data:    bitmap,
+		},
+		{
+			name:    "PreferPNG",
+			drawing: drawingWithPreview(previewEntry{PreviewBMP, bitmap}, previewEntry{PreviewPNG, pngData}, previewEntry{PreviewWMF, metafile}),
+			format:  PreviewPNG,
+			data:    pngData,
+		},
+		{
+			name:    "Metafile",
+			drawing: drawingWithPreview(previewEntry{PreviewWMF, metafile}),
+			format:  PreviewWMF,
+			data:    metafile,
+		},
+		{
+			name:    "HeaderOnly",
+			drawing: drawingWithPreview(previewEntry{1, headerData}),
+			err:     ErrNoPreview,
+		},
+		{
+			name:    "NoOffset",
+			drawing: r13Header("AC1015", 0, 0, 30),
+			err:     ErrNoPreview,
+		},
+		{
+			name:    "BadSentinel",
+			drawing: append(r13Header("AC1015", 0, 0x19, 30), make([]byte, 32)...),
+			err:     ErrInvalidPreview,
+		},
+		{
+			name:    "Oversized",
+			drawing: oversizedPreview(),
+			err:     ErrInvalidPreview,
+		},
+		{
+			name:    "OutOfBounds",
+			drawing: drawingWithPreview(previewEntry{PreviewBMP, bitmap})[:0x60],
+			err:     ErrInvalidPreview,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadPreview(bytes.NewReader(tt.drawing))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ReadPreview() error = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if got.Format != tt.format {
+				t.Errorf("ReadPreview() format = %v, want %v", got.Format, tt.format)
+			}
+			if !bytes.Equal(got.Data, tt.data) {
+				t.Errorf("ReadPreview() data = %x, want %x", got.Data, tt.data)
+			}
+		})
+	}
+}
+
+func TestPreviewImage(t *testing.T) {
+	t.Run("Bitmap", func(t *testing.T) {
+		img, err := (&Preview{Format: PreviewBMP, Data: testBitmap()}).Image()
+		if err != nil {
+			t.Fatalf("Image() error = %v", err)
+		}
+		if got := img.Bounds(); got != image.Rect(0, 0, 2, 2) {
+			t.Fatalf("Image() bounds = %v, want 2x2", got)
+		}
+		want := map[image.Point]color.RGBA{
+			{0, 0}: {0, 0, 255, 255},
+			{1, 0}: {255, 255, 255, 255},
+			{0, 1}: {255, 0, 0, 255},
+			{1, 1}: {0, 255, 0, 255},
+		}
+		for p, c := range want {
+			if got := color.RGBAModel.Convert(img.At(p.X, p.Y)); got != c {
+				t.Errorf("Image().At(%d, %d) = %v, want %v", p.X, p.Y, got, c)
+			}
+		}
+	})
+
+	t.Run("PNG", func(t *testing.T) {
+		img, err := (&Preview{Format: PreviewPNG, Data: testPNG(t)}).Image()
+		if err != nil {
+			t.Fatalf("Image() error = %v", err)
+		}
+		if got := img.Bounds(); got != image.Rect(0, 0, 1, 1) {
+			t.Fatalf("Image() bounds = %v, want 1x1", got)
+		}
+	})
+
+	t.Run("Metafile", func(t *testing.T) {
+		if _, err := (&Preview{Format: PreviewWMF}).Image(); err != ErrUnsupportedPreview {
+			t.Fatalf("Image() error = %v, want %v", err, ErrUnsupportedPreview)
+		}
+	})
+}
+
+// eofReader is an io.ReaderAt that returns io.EOF along with reads that
+// reach the end of its data, as io.ReaderAt allows.
+type eofReader []byte
+
+func (r eofReader) ReadAt(b []byte, offset int64) (int, error) {
+	n, err := bytes.NewReader(r).ReadAt(b, offset)
+	if err == nil && offset+int64(n) == int64(len(r)) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func TestReadPreviewAtEnd(t *testing.T) {
+	bitmap := testBitmap()
+	drawing := drawingWithPreview(previewEntry{PreviewBMP, bitmap})
+
+	got, err := ReadPreview(eofReader(drawing))
+	if err != nil {
+		t.Fatalf("ReadPreview() error = %v", err)
+	}
+	if !bytes.Equal(got.Data, bitmap) {
+		t.Errorf("ReadPreview() data = %x, want %x", got.Data, bitmap)
+	}
+}
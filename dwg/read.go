@@ -0,0 +1,78 @@
+package dwg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	// ErrInvalidDrawing is returned when a file does not posses a drawing
+	// header.
+	ErrInvalidDrawing = errors.New("not a drawing file")
+
+	// ErrTruncated is returned when a file ends before its drawing header is
+	// complete. It wraps ErrInvalidDrawing.
+	ErrTruncated = fmt.Errorf("%w: truncated header", ErrInvalidDrawing)
+)
+
+// ReadFileVersion attempts to open the file with the given name and return
+// its drawing format header.
+func ReadFileVersion(name string) (Version, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return ReadVersion(f)
+}
+
+// ReadVersion returns the drawing format header at the start of r.
+func ReadVersion(r io.ReaderAt) (Version, error) {
+	var header [6]byte
+
+	n, err := r.ReadAt(header[:], 0)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	if n < 6 {
+		if isHeaderPrefix(header[:n]) {
+			return "", ErrTruncated
+		}
+		return "", ErrInvalidDrawing
+	}
+
+	h := header[:]
+	switch {
+	case h[0] == 'A' && h[1] == 'C':
+		switch {
+		case h[2] == '1' && h[3] == '.' && h[4] == '2':
+			return "AC1.2", nil
+		case h[2] == '1' && h[3] == '.' && h[4] == '4':
+			return "AC1.4", nil
+		default:
+			return Version(h), nil
+		}
+	case h[0] == 'M' && h[1] == 'C' && h[2] == '0' && h[3] == '.' && h[4] == '0':
+		return "MC0.0", nil
+	default:
+		return "", ErrInvalidDrawing
+	}
+}
+
+// isHeaderPrefix reports whether b could be the start of a drawing header.
+func isHeaderPrefix(b []byte) bool {
+	for _, magic := range []string{"AC", "MC0.0"} {
+		if len(b) <= len(magic) {
+			if bytes.HasPrefix([]byte(magic), b) {
+				return true
+			}
+		} else if bytes.HasPrefix(b, []byte(magic)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package dwg
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   Version
+		err    error
+	}{
+		{"R1.1", []byte("MC0.0\x00\x00\x00"), "MC0.0", nil},
+		{"R1.2", []byte("AC1.2\x00\x00\x00"), "AC1.2", nil},
+		{"R1.4", []byte("AC1.40\x00\x00"), "AC1.4", nil},
+		{"R2.0", []byte("AC1.50\x00\x00"), "AC1.50", nil},
+		{"R2.10", []byte("AC2.10\x00\x00"), "AC2.10", nil},
+		{"R12", []byte("AC1009\x00\x00\x00\x00"), "AC1009", nil},
+		{"R14", []byte("AC1014\x00\x00\x00\x00\x00\x00"), "AC1014", nil},
+		{"R2000", []byte("AC1015\x00\x00\x00\x00\x00\x00"), "AC1015", nil},
+		{"R2018", []byte("AC1032\x00\x00\x00\x00\x00\x00"), "AC1032", nil},
+		{"ExactLength", []byte("AC1027"), "AC1027", nil},
+		{"FutureVersion", []byte("AC1040\x00\x00"), "AC1040", nil},
+		{"Empty", []byte{}, "", ErrTruncated},
+		{"Truncated", []byte("AC10"), "", ErrTruncated},
+		{"TruncatedMagic", []byte("M"), "", ErrTruncated},
+		{"Short", []byte("hi\n"), "", ErrInvalidDrawing},
+		{"Text", []byte("hello world"), "", ErrInvalidDrawing},
+		{"DXF", []byte("  0\r\nSECTION\r\n"), "", ErrInvalidDrawing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadVersion(bytes.NewReader(tt.header))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ReadVersion() error = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Fatalf("ReadVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadFileVersion(t *testing.T) {
+	dir := t.TempDir()
+
+	name := filepath.Join(dir, "drawing.dwg")
+	if err := os.WriteFile(name, []byte("AC1024\x00\x00\x00\x00\x00\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadFileVersion(name)
+	if err != nil {
+		t.Fatalf("ReadFileVersion() error = %v", err)
+	}
+	if got != "AC1024" {
+		t.Fatalf("ReadFileVersion() = %q, want %q", got, "AC1024")
+	}
+
+	if _, err := ReadFileVersion(filepath.Join(dir, "missing.dwg")); !os.IsNotExist(err) {
+		t.Fatalf("ReadFileVersion() on missing file error = %v, want not exist", err)
+	}
+}
+
+func TestErrTruncatedIsInvalidDrawing(t *testing.T) {
+	if !errors.Is(ErrTruncated, ErrInvalidDrawing) {
+		t.Fatal("ErrTruncated should wrap ErrInvalidDrawing")
+	}
+}
@@ -0,0 +1,346 @@
+package dwg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var (
+	// ErrUnsupportedFormat is returned when data is requested from a drawing
+	// whose file format does not store it in a way this package can read.
+	ErrUnsupportedFormat = errors.New("unsupported drawing file format")
+
+	// ErrCorrupt is returned when a drawing's internal structures are
+	// malformed.
+	ErrCorrupt = errors.New("corrupt drawing data")
+
+	// ErrEncrypted is returned when requested data is protected by a
+	// password.
+	ErrEncrypted = errors.New("drawing data is encrypted")
+
+	// ErrNoSection is returned when a drawing does not contain a requested
+	// section.
+	ErrNoSection = errors.New("drawing section not found")
+)
+
+// Section page types.
+const (
+	pageTypePageMap    = 0x41630E3B
+	pageTypeSectionMap = 0x4163003B
+	pageTypeData       = 0x4163043B
+)
+
+// maxSectionSize limits the memory used to read a single section. The
+// sections read by this package are much smaller.
+const maxSectionSize = 16 << 20
+
+// sectionFile provides access to the named sections of an AutoCAD 2004 or
+// later drawing.
+//
+// These drawings are divided into pages. A page map records where each page
+// is, and a section map records which pages belong to each section. Both
+// maps are located through an encrypted block in the file header.
+type sectionFile struct {
+	r        io.ReaderAt
+	header   *Header
+	pages    map[int32]int64 // Page number to file offset
+	sections map[string]sectionInfo
+}
+
+// sectionInfo describes a section and the pages that hold its data.
+type sectionInfo struct {
+	size       uint64
+	maxPage    uint32 // Decompressed size of each page
+	compressed bool
+	encrypted  bool
+	pages      []sectionPage
+}
+
+// sectionPage describes one page of a section.
+type sectionPage struct {
+	number int32
+	size   uint32 // Compressed size of the page data
+	offset uint64 // Offset of the page data within the section
+}
+
+// usesSections reports whether drawings of version v store their data in
+// pages described by a section map. AutoCAD 2007 drawings use a different,
+// unsupported layout.
+func usesSections(v Version) bool {
+	switch v {
+	case "AC1018", "AC1024", "AC1027", "AC1032":
+		return true
+	default:
+		return false
+	}
+}
+
+// Sections provides access to the data held in the sections of an AutoCAD
+// 2004 or later drawing. Opening the sections once lets several kinds of
+// data be read without locating them in the drawing again.
+type Sections struct {
+	f *sectionFile
+}
+
+// OpenSections reads the page and section maps of the drawing in r.
+//
+// Only AutoCAD 2004 and later drawings other than AutoCAD 2007 drawings store
+// their data in sections that can be read. Other drawings return
+// ErrUnsupportedFormat.
+func OpenSections(r io.ReaderAt) (*Sections, error) {
+	f, err := openSections(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Sections{f: f}, nil
+}
+
+// openSections reads the page and section maps of the drawing in r.
+func openSections(r io.ReaderAt) (*sectionFile, error) {
+	header, err := ReadHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if !usesSections(header.Version) {
+		return nil, ErrUnsupportedFormat
+	}
+
+	// The encrypted part of the file header locates the maps
+	encrypted := make([]byte, 0x6C)
+	if err := readAt(r, encrypted, 0x80); err != nil {
+		return nil, err
+	}
+	decryptHeader(encrypted)
+	if !bytes.HasPrefix(encrypted, []byte("AcFssFcAJMB\x00")) {
+		return nil, ErrCorrupt
+	}
+
+	le := binary.LittleEndian
+	pageMapAddress := int64(le.Uint64(encrypted[0x54:])) + 0x100
+	sectionMapID := int32(le.Uint32(encrypted[0x5C:]))
+
+	f := &sectionFile{r: r, header: header}
+
+	pageMap, err := f.readSystemPage(pageMapAddress, pageTypePageMap)
+	if err != nil {
+		return nil, err
+	}
+	if f.pages, err = parsePageMap(pageMap); err != nil {
+		return nil, err
+	}
+
+	address, ok := f.pages[sectionMapID]
+	if !ok {
+		return nil, ErrCorrupt
+	}
+	sectionMap, err := f.readSystemPage(address, pageTypeSectionMap)
+	if err != nil {
+		return nil, err
+	}
+	if f.sections, err = parseSectionMap(sectionMap); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// decryptHeader decrypts the encrypted block of an AutoCAD 2004 or later
+// file header in place.
+func decryptHeader(b []byte) {
+	seed := uint32(1)
+	for i := range b {
+		seed = seed*0x343FD + 0x269EC3
+		b[i] ^= byte(seed >> 16)
+	}
+}
+
+// readSystemPage reads and decompresses the page map or section map page at
+// the given file offset.
+func (f *sectionFile) readSystemPage(address int64, pageType uint32) ([]byte, error) {
+	var header [20]byte
+	if err := readAt(f.r, header[:], address); err != nil {
+		return nil, err
+	}
+
+	le := binary.LittleEndian
+	if le.Uint32(header[0:]) != pageType {
+		return nil, ErrCorrupt
+	}
+	size := le.Uint32(header[4:])
+	compressedSize := le.Uint32(header[8:])
+	compression := le.Uint32(header[12:])
+	if size > maxSectionSize || compressedSize > maxSectionSize {
+		return nil, ErrCorrupt
+	}
+
+	data := make([]byte, compressedSize)
+	if err := readAt(f.r, data, address+20); err != nil {
+		return nil, err
+	}
+
+	if compression != 2 {
+		return data, nil
+	}
+	return decompress(data, int(size))
+}
+
+// parsePageMap returns the file offset of each page listed in a page map.
+func parsePageMap(b []byte) (map[int32]int64, error) {
+	le := binary.LittleEndian
+	pages := make(map[int32]int64)
+
+	address := int64(0x100)
+	for len(b) >= 8 {
+		number := int32(le.Uint32(b[0:]))
+		size := int64(le.Uint32(b[4:]))
+		b = b[8:]
+
+		if number < 0 {
+			// Gaps are followed by the numbers of their neighbors in a tree
+			// of free pages, which aren't needed
+			if len(b) < 16 {
+				return nil, ErrCorrupt
+			}
+			b = b[16:]
+		} else {
+			pages[number] = address
+		}
+
+		address += size
+	}
+
+	return pages, nil
+}
+
+// parseSectionMap returns the description of each section listed in a
+// section map, keyed by section name.
+func parseSectionMap(b []byte) (map[string]sectionInfo, error) {
+	le := binary.LittleEndian
+	if len(b) < 20 {
+		return nil, ErrCorrupt
+	}
+	count := int(le.Uint32(b[0:]))
+	b = b[20:]
+
+	// Each section takes at least 96 bytes, so a larger count is corrupt and
+	// mustn't be used to size the map
+	if count > len(b)/96 {
+		return nil, ErrCorrupt
+	}
+
+	sections := make(map[string]sectionInfo, count)
+	for i := 0; i < count; i++ {
+		if len(b) < 96 {
+			return nil, ErrCorrupt
+		}
+		info := sectionInfo{
+			size:       le.Uint64(b[0:]),
+			maxPage:    le.Uint32(b[12:]),
+			compressed: le.Uint32(b[20:]) == 2,
+			encrypted:  le.Uint32(b[28:]) == 1,
+		}
+		pageCount := int(le.Uint32(b[8:]))
+		name := string(bytes.TrimRight(b[32:96], "\x00"))
+		b = b[96:]
+
+		if len(b) < pageCount*16 {
+			return nil, ErrCorrupt
+		}
+		for p := 0; p < pageCount; p++ {
+			info.pages = append(info.pages, sectionPage{
+				number: int32(le.Uint32(b[0:])),
+				size:   le.Uint32(b[4:]),
+				offset: le.Uint64(b[8:]),
+			})
+			b = b[16:]
+		}
+
+		if name != "" {
+			sections[name] = info
+		}
+	}
+
+	return sections, nil
+}
+
+// read returns the decompressed contents of the named section.
+func (f *sectionFile) read(name string) ([]byte, error) {
+	info, ok := f.sections[name]
+	if !ok {
+		return nil, ErrNoSection
+	}
+	if info.encrypted {
+		return nil, ErrEncrypted
+	}
+	if info.size > maxSectionSize || info.maxPage > maxSectionSize {
+		return nil, ErrCorrupt
+	}
+
+	data := make([]byte, info.size)
+	for _, page := range info.pages {
+		if page.offset >= info.size {
+			return nil, ErrCorrupt
+		}
+		b, err := f.readDataPage(page, info)
+		if err != nil {
+			return nil, err
+		}
+		copy(data[page.offset:], b)
+	}
+
+	return data, nil
+}
+
+// readDataPage reads and decompresses one page of a section.
+func (f *sectionFile) readDataPage(page sectionPage, info sectionInfo) ([]byte, error) {
+	address, ok := f.pages[page.number]
+	if !ok {
+		return nil, ErrCorrupt
+	}
+
+	// The page header is masked with a value derived from its location
+	var header [32]byte
+	if err := readAt(f.r, header[:], address); err != nil {
+		return nil, err
+	}
+	le := binary.LittleEndian
+	mask := 0x4164536B ^ uint32(address)
+	for i := 0; i < len(header); i += 4 {
+		le.PutUint32(header[i:], le.Uint32(header[i:])^mask)
+	}
+	if le.Uint32(header[0:]) != pageTypeData {
+		return nil, ErrCorrupt
+	}
+
+	size := le.Uint32(header[8:])
+	if size > maxSectionSize {
+		return nil, ErrCorrupt
+	}
+	data := make([]byte, size)
+	if err := readAt(f.r, data, address+32); err != nil {
+		return nil, err
+	}
+
+	if !info.compressed {
+		return data, nil
+	}
+	return decompress(data, int(info.maxPage))
+}
+
+// readAt fills b from r at the given offset. Reaching the end of r first is
+// reported as ErrCorrupt.
+func readAt(r io.ReaderAt, b []byte, offset int64) error {
+	if offset < 0 {
+		return ErrCorrupt
+	}
+	n, err := r.ReadAt(b, offset)
+	if n == len(b) {
+		return nil
+	}
+	if err == nil || err == io.EOF {
+		return ErrCorrupt
+	}
+	return err
+}
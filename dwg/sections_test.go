@@ -0,0 +1,236 @@
+package dwg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// testSection is a section to be stored in a synthetic drawing.
+type testSection struct {
+	name      string
+	data      []byte
+	encrypted bool
+}
+
+// sectionDrawing returns a synthetic AutoCAD 2004 or later drawing that
+// holds the given sections, each in a single compressed page.
+//
+// The drawing's pages are laid out as the data pages of each section in
+// turn, then the section map and finally the page map.
+func sectionDrawing(version string, header Header, sections ...testSection) []byte {
+	le := binary.LittleEndian
+	file := r2004Header(version, header)
+
+	var pageSizes []int
+
+	// Data pages
+	for i, section := range sections {
+		address := len(file)
+		compressed := compressLiterals(section.data)
+
+		var page [32]byte
+		le.PutUint32(page[0:], pageTypeData)
+		le.PutUint32(page[4:], uint32(i+1))
+		le.PutUint32(page[8:], uint32(len(compressed)))
+		le.PutUint32(page[12:], uint32(len(section.data)))
+		mask := 0x4164536B ^ uint32(address)
+		for j := 0; j < len(page); j += 4 {
+			le.PutUint32(page[j:], le.Uint32(page[j:])^mask)
+		}
+
+		file = append(file, page[:]...)
+		file = append(file, compressed...)
+		pageSizes = append(pageSizes, len(file)-address)
+	}
+
+	// Section map
+	var sectionMap []byte
+	sectionMap = le.AppendUint32(sectionMap, uint32(len(sections)))
+	sectionMap = le.AppendUint32(sectionMap, 2)
+	sectionMap = le.AppendUint32(sectionMap, 0x7400)
+	sectionMap = le.AppendUint32(sectionMap, 0)
+	sectionMap = le.AppendUint32(sectionMap, uint32(len(sections)))
+	for i, section := range sections {
+		sectionMap = le.AppendUint64(sectionMap, uint64(len(section.data)))
+		sectionMap = le.AppendUint32(sectionMap, 1)                         // Page count
+		sectionMap = le.AppendUint32(sectionMap, uint32(len(section.data))) // Page size
+		sectionMap = le.AppendUint32(sectionMap, 1)
+		sectionMap = le.AppendUint32(sectionMap, 2) // Compressed
+		sectionMap = le.AppendUint32(sectionMap, uint32(i+1))
+		encrypted := uint32(0)
+		if section.encrypted {
+			encrypted = 1
+		}
+		sectionMap = le.AppendUint32(sectionMap, encrypted)
+		var name [64]byte
+		copy(name[:], section.name)
+		sectionMap = append(sectionMap, name[:]...)
+		sectionMap = le.AppendUint32(sectionMap, uint32(i+1)) // Page number
+		sectionMap = le.AppendUint32(sectionMap, uint32(pageSizes[i]))
+		sectionMap = le.AppendUint64(sectionMap, 0) // Offset within section
+	}
+	sectionMapAddress := len(file)
+	file = appendSystemPage(file, pageTypeSectionMap, sectionMap)
+	pageSizes = append(pageSizes, len(file)-sectionMapAddress)
+	sectionMapID := len(pageSizes)
+
+	// Page map, which includes a gap and its own page
+	pageMapAddress := len(file)
+	pageMapSize := 20 + len(compressLiterals(make([]byte, (len(pageSizes)+1)*8+24)))
+	var pageMap []byte
+	for i, size := range pageSizes {
+		pageMap = le.AppendUint32(pageMap, uint32(i+1))
+		pageMap = le.AppendUint32(pageMap, uint32(size))
+	}
+	pageMap = le.AppendUint32(pageMap, uint32(len(pageSizes)+1))
+	pageMap = le.AppendUint32(pageMap, uint32(pageMapSize))
+	negative := int32(-1)
+	pageMap = le.AppendUint32(pageMap, uint32(negative))
+	pageMap = le.AppendUint32(pageMap, 0)
+	pageMap = append(pageMap, make([]byte, 16)...)
+	file = appendSystemPage(file, pageTypePageMap, pageMap)
+
+	// Encrypted file header
+	encrypted := make([]byte, 0x6C)
+	copy(encrypted, "AcFssFcAJMB\x00")
+	le.PutUint64(encrypted[0x54:], uint64(pageMapAddress-0x100))
+	le.PutUint32(encrypted[0x5C:], uint32(sectionMapID))
+	decryptHeader(encrypted)
+	copy(file[0x80:], encrypted)
+
+	return file
+}
+
+// appendSystemPage appends a compressed page map or section map page to b.
+func appendSystemPage(b []byte, pageType uint32, data []byte) []byte {
+	le := binary.LittleEndian
+	compressed := compressLiterals(data)
+	b = le.AppendUint32(b, pageType)
+	b = le.AppendUint32(b, uint32(len(data)))
+	b = le.AppendUint32(b, uint32(len(compressed)))
+	b = le.AppendUint32(b, 2)
+	b = le.AppendUint32(b, 0)
+	return append(b, compressed...)
+}
+
+func TestReadSection(t *testing.T) {
+	drawing := sectionDrawing("AC1032", Header{},
+		testSection{name: "AcDb:Header", data: bytes.Repeat([]byte("header"), 10)},
+		testSection{name: "AcDb:Secret", data: []byte("secret data"), encrypted: true},
+		testSection{name: "AcDb:Other", data: []byte("other section")},
+	)
+
+	f, err := openSections(bytes.NewReader(drawing))
+	if err != nil {
+		t.Fatalf("openSections() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want []byte
+		err  error
+	}{
+		{"AcDb:Header", bytes.Repeat([]byte("header"), 10), nil},
+		{"AcDb:Other", []byte("other section"), nil},
+		{"AcDb:Secret", nil, ErrEncrypted},
+		{"AcDb:Missing", nil, ErrNoSection},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.read(tt.name)
+			if err != tt.err {
+				t.Fatalf("read() error = %v, want %v", err, tt.err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("read() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenSectionsErrors(t *testing.T) {
+	valid := sectionDrawing("AC1018", Header{}, testSection{name: "AcDb:Header", data: []byte("data")})
+
+	badHeader := append([]byte(nil), valid...)
+	badHeader[0x80] ^= 0xFF
+
+	badPageMap := append([]byte(nil), valid...)
+	badPageMap = badPageMap[:len(badPageMap)-10]
+
+	tests := []struct {
+		name    string
+		drawing []byte
+		err     error
+	}{
+		{"R2000", r13Header("AC1015", 0, 0, 30), ErrUnsupportedFormat},
+		{"R2007", r2004Header("AC1021", Header{}), ErrUnsupportedFormat},
+		{"BadHeader", badHeader, ErrCorrupt},
+		{"TruncatedPageMap", badPageMap, ErrCorrupt},
+		{"NoPages", r2004Header("AC1024", Header{}), ErrCorrupt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := openSections(bytes.NewReader(tt.drawing)); !errors.Is(err, tt.err) {
+				t.Fatalf("openSections() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseSectionMapCount(t *testing.T) {
+	le := binary.LittleEndian
+	sectionMap := le.AppendUint32(nil, 0xFFFFFFFF)
+	sectionMap = append(sectionMap, make([]byte, 16+96)...)
+
+	if _, err := parseSectionMap(sectionMap); err != ErrCorrupt {
+		t.Fatalf("parseSectionMap() error = %v, want %v", err, ErrCorrupt)
+	}
+}
+
+// countingReader counts the reads of the encrypted file header.
+type countingReader struct {
+	r           *bytes.Reader
+	headerReads int
+}
+
+func (c *countingReader) ReadAt(b []byte, offset int64) (int, error) {
+	if offset == 0x80 {
+		c.headerReads++
+	}
+	return c.r.ReadAt(b, offset)
+}
+
+func TestSectionsShared(t *testing.T) {
+	drawing := sectionDrawing("AC1032", Header{},
+		testSection{name: "AcDb:SummaryInfo", data: summaryInfoSection(SummaryInfo{Title: "Plan"}, true)},
+		testSection{name: "AcDb:AppInfo", data: appInfoSection("AC1032", AppInfo{Version: "24.3"}, "")},
+	)
+	r := &countingReader{r: bytes.NewReader(drawing)}
+
+	s, err := OpenSections(r)
+	if err != nil {
+		t.Fatalf("OpenSections() error = %v", err)
+	}
+	info, err := s.SummaryInfo()
+	if err != nil {
+		t.Fatalf("SummaryInfo() error = %v", err)
+	}
+	if info.Title != "Plan" {
+		t.Errorf("SummaryInfo().Title = %q, want %q", info.Title, "Plan")
+	}
+	app, err := s.AppInfo()
+	if err != nil {
+		t.Fatalf("AppInfo() error = %v", err)
+	}
+	if app.Version != "24.3" {
+		t.Errorf("AppInfo().Version = %q, want %q", app.Version, "24.3")
+	}
+
+	if r.headerReads != 1 {
+		t.Fatalf("read the encrypted header %d times, want 1", r.headerReads)
+	}
+}
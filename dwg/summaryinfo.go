@@ -0,0 +1,178 @@
+package dwg
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Property is a custom drawing property.
+type Property struct {
+	Name  string
+	Value string
+}
+
+// SummaryInfo holds the drawing properties shown in AutoCAD's DWGPROPS
+// dialog.
+type SummaryInfo struct {
+	Title            string
+	Subject          string
+	Author           string
+	Keywords         string
+	Comments         string
+	LastSavedBy      string
+	RevisionNumber   string
+	HyperlinkBase    string
+	TotalEditingTime time.Duration
+	Created          time.Time
+	Modified         time.Time
+	Custom           []Property
+}
+
+// ReadFileSummaryInfo attempts to open the file with the given name and
+// return its drawing properties.
+func ReadFileSummaryInfo(name string) (*SummaryInfo, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadSummaryInfo(f)
+}
+
+// ReadSummaryInfo returns the drawing properties stored in the SummaryInfo
+// section of the drawing in r.
+//
+// Only AutoCAD 2004 and later drawings store their properties in a section
+// that can be read. AutoCAD 2007 drawings, and older drawings, return
+// ErrUnsupportedFormat. Drawings with encrypted properties return
+// ErrEncrypted.
+func ReadSummaryInfo(r io.ReaderAt) (*SummaryInfo, error) {
+	s, err := OpenSections(r)
+	if err != nil {
+		return nil, err
+	}
+	return s.SummaryInfo()
+}
+
+// SummaryInfo returns the drawing properties stored in the SummaryInfo
+// section. Drawings with encrypted properties return ErrEncrypted.
+func (s *Sections) SummaryInfo() (*SummaryInfo, error) {
+	f := s.f
+	if f.header.Security&EncryptProperties != 0 {
+		return nil, ErrEncrypted
+	}
+
+	data, err := f.read("AcDb:SummaryInfo")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSummaryInfo(data, f.header)
+}
+
+// parseSummaryInfo decodes the contents of a SummaryInfo section.
+func parseSummaryInfo(data []byte, header *Header) (*SummaryInfo, error) {
+	d := &sectionDecoder{
+		b:        data,
+		unicode:  header.Version.Release() >= Version("AC1021").Release(),
+		codepage: header.Codepage,
+	}
+
+	info := &SummaryInfo{
+		Title:          d.string(),
+		Subject:        d.string(),
+		Author:         d.string(),
+		Keywords:       d.string(),
+		Comments:       d.string(),
+		LastSavedBy:    d.string(),
+		RevisionNumber: d.string(),
+		HyperlinkBase:  d.string(),
+	}
+
+	days, ms := d.uint32(), d.uint32()
+	info.TotalEditingTime = time.Duration(days)*24*time.Hour + time.Duration(ms)*time.Millisecond
+	info.Created = julianTime(d.uint32(), d.uint32())
+	info.Modified = julianTime(d.uint32(), d.uint32())
+
+	count := int(d.uint16())
+	for i := 0; i < count && d.err == nil; i++ {
+		name, value := d.string(), d.string()
+		info.Custom = append(info.Custom, Property{Name: name, Value: value})
+	}
+
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	return info, nil
+}
+
+// julianTime converts a Julian day number and a number of milliseconds into
+// that day to a time. A day of zero is returned as the zero time.
+func julianTime(day, ms uint32) time.Time {
+	if day == 0 {
+		return time.Time{}
+	}
+	const unixEpoch = 2440588 // Julian day number of 1970-01-01
+	return time.Unix((int64(day)-unixEpoch)*24*60*60, int64(ms)*int64(time.Millisecond)).UTC()
+}
+
+// sectionDecoder reads values from the contents of a section. Once an error
+// occurs, further reads return zero values.
+type sectionDecoder struct {
+	b        []byte
+	unicode  bool     // Strings are UTF-16 rather than codepage text
+	codepage Codepage // Codepage of non-Unicode strings
+	err      error
+}
+
+func (d *sectionDecoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.b) {
+		d.err = ErrCorrupt
+		return nil
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b
+}
+
+func (d *sectionDecoder) uint16() uint16 {
+	b := d.take(2)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(b)
+}
+
+func (d *sectionDecoder) uint32() uint32 {
+	b := d.take(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+// string reads a string preceded by its 16-bit length in characters.
+// Terminating nulls are removed.
+func (d *sectionDecoder) string() string {
+	length := int(d.uint16())
+	if !d.unicode {
+		b := d.take(length)
+		return strings.TrimRight(d.codepage.Decode(b), "\x00")
+	}
+
+	b := d.take(length * 2)
+	chars := make([]uint16, len(b)/2)
+	for i := range chars {
+		chars[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return strings.TrimRight(string(utf16.Decode(chars)), "\x00")
+}
@@ -0,0 +1,122 @@
+package dwg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// summaryInfoSection encodes info as the contents of a SummaryInfo section.
+// Strings are written as UTF-16 when unicode is true and as Windows-1252
+// otherwise.
+func summaryInfoSection(info SummaryInfo, unicode bool) []byte {
+	le := binary.LittleEndian
+	var b []byte
+	str := func(s string) { b = appendSectionString(b, s, unicode) }
+	julian := func(t time.Time) {
+		if t.IsZero() {
+			b = le.AppendUint32(b, 0)
+			b = le.AppendUint32(b, 0)
+			return
+		}
+		day := t.Unix()/(24*60*60) + 2440588
+		ms := t.Sub(time.Unix((day-2440588)*24*60*60, 0)) / time.Millisecond
+		b = le.AppendUint32(b, uint32(day))
+		b = le.AppendUint32(b, uint32(ms))
+	}
+
+	for _, s := range []string{info.Title, info.Subject, info.Author, info.Keywords, info.Comments, info.LastSavedBy, info.RevisionNumber, info.HyperlinkBase} {
+		str(s)
+	}
+	day := 24 * time.Hour
+	b = le.AppendUint32(b, uint32(info.TotalEditingTime/day))
+	b = le.AppendUint32(b, uint32(info.TotalEditingTime%day/time.Millisecond))
+	julian(info.Created)
+	julian(info.Modified)
+	b = le.AppendUint16(b, uint16(len(info.Custom)))
+	for _, p := range info.Custom {
+		str(p.Name)
+		str(p.Value)
+	}
+	return b
+}
+
+// appendSectionString appends s to b as a section string, which is either
+// UTF-16 or Windows-1252 text with a terminating null.
+func appendSectionString(b []byte, s string, unicode bool) []byte {
+	le := binary.LittleEndian
+	if unicode {
+		chars := append(utf16.Encode([]rune(s)), 0)
+		b = le.AppendUint16(b, uint16(len(chars)))
+		for _, c := range chars {
+			b = le.AppendUint16(b, c)
+		}
+		return b
+	}
+
+	var text []byte
+	for _, r := range s {
+		text = append(text, byte(r)) // Latin-1 subset of Windows-1252
+	}
+	text = append(text, 0)
+	b = le.AppendUint16(b, uint16(len(text)))
+	return append(b, text...)
+}
+
+func TestReadSummaryInfo(t *testing.T) {
+	info := SummaryInfo{
+		Title:            "Site Plan",
+		Subject:          "Façade",
+		Author:           "J. Smith",
+		Keywords:         "site; plan",
+		Comments:         "Issued for review",
+		LastSavedBy:      "jsmith",
+		RevisionNumber:   "C",
+		HyperlinkBase:    `\\server\projects`,
+		TotalEditingTime: 50*time.Hour + 1500*time.Millisecond,
+		Created:          time.Date(2019, 3, 14, 9, 26, 53, 589e6, time.UTC),
+		Modified:         time.Date(2023, 11, 2, 16, 5, 0, 0, time.UTC),
+		Custom: []Property{
+			{Name: "Project", Value: "1042"},
+			{Name: "Client", Value: "Café Ltd."},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		version string
+		header  Header
+		data    []byte
+		want    *SummaryInfo
+		err     error
+	}{
+		{"R2004", "AC1018", Header{Codepage: 30}, summaryInfoSection(info, false), &info, nil},
+		{"R2018", "AC1032", Header{Codepage: 30}, summaryInfoSection(info, true), &info, nil},
+		{"Empty", "AC1024", Header{}, summaryInfoSection(SummaryInfo{}, true), &SummaryInfo{}, nil},
+		{"Encrypted", "AC1032", Header{Security: EncryptProperties}, summaryInfoSection(info, true), nil, ErrEncrypted},
+		{"Truncated", "AC1027", Header{}, summaryInfoSection(info, true)[:40], nil, ErrCorrupt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drawing := sectionDrawing(tt.version, tt.header, testSection{name: "AcDb:SummaryInfo", data: tt.data})
+			got, err := ReadSummaryInfo(bytes.NewReader(drawing))
+			if err != tt.err {
+				t.Fatalf("ReadSummaryInfo() error = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ReadSummaryInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSummaryInfoMissing(t *testing.T) {
+	drawing := sectionDrawing("AC1018", Header{}, testSection{name: "AcDb:Header", data: []byte("header")})
+	if _, err := ReadSummaryInfo(bytes.NewReader(drawing)); err != ErrNoSection {
+		t.Fatalf("ReadSummaryInfo() error = %v, want %v", err, ErrNoSection)
+	}
+}
@@ -0,0 +1,107 @@
+// Package dwg reads version information from AutoCAD drawing files.
+//
+// The package has no platform dependencies and can be used independently of
+// the cadscan application.
+package dwg
+
+// Version is a drawing version string from a drawing header.
+//
+// The string should match one of values specified in this document:
+// https://knowledge.autodesk.com/support/autocad/learn-explore/caas/sfdcarticles/sfdcarticles/drawing-version-codes-for-autocad.html
+type Version string
+
+// String returns a string representation of the drawing version.
+func (v Version) String() string {
+	return string(v)
+}
+
+// Release returns an ordered integer representing the drawing release.
+//
+// Later releases have higher numbers. Unknown versions return 0.
+func (v Version) Release() int {
+	switch v {
+	default:
+		return 0
+	case "MC0.0":
+		return 1
+	case "AC1.2":
+		return 2
+	case "AC1.4":
+		return 3
+	case "AC1.50":
+		return 4
+	case "AC2.10":
+		return 5
+	case "AC1002":
+		return 6
+	case "AC1003":
+		return 7
+	case "AC1004":
+		return 8
+	case "AC1006":
+		return 9
+	case "AC1009":
+		return 10
+	case "AC1012":
+		return 11
+	case "AC1014":
+		return 12
+	case "AC1015":
+		return 13
+	case "AC1018":
+		return 14
+	case "AC1021":
+		return 15
+	case "AC1024":
+		return 16
+	case "AC1027":
+		return 17
+	case "AC1032":
+		return 18
+	}
+}
+
+// ReleaseName returns a description of which versions of AutoCAD the drawing
+// is supported in. Unknown versions return an empty string.
+func (v Version) ReleaseName() string {
+	switch v {
+	case "MC0.0":
+		return "Release 1.1"
+	case "AC1.2":
+		return "Release 1.2"
+	case "AC1.4":
+		return "Release 1.4"
+	case "AC1.50":
+		return "Release 2.0"
+	case "AC2.10":
+		return "Release 2.10"
+	case "AC1002":
+		return "Release 2.5"
+	case "AC1003":
+		return "Release 2.6"
+	case "AC1004":
+		return "Release 9"
+	case "AC1006":
+		return "Release 10"
+	case "AC1009":
+		return "Release 11/12 (LT R1/R2)"
+	case "AC1012":
+		return "Release 13 (LT95)"
+	case "AC1014":
+		return "Release 14, 14.01 (LT97/LT98)"
+	case "AC1015":
+		return "AutoCAD 2000/2000i/2002"
+	case "AC1018":
+		return "AutoCAD 2004-2006"
+	case "AC1021":
+		return "AutoCAD 2007-2009"
+	case "AC1024":
+		return "AutoCAD 2010-2012"
+	case "AC1027":
+		return "AutoCAD 2013-2017"
+	case "AC1032":
+		return "AutoCAD 2018+"
+	default:
+		return ""
+	}
+}
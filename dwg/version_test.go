@@ -0,0 +1,57 @@
+package dwg
+
+import "testing"
+
+func TestVersionRelease(t *testing.T) {
+	tests := []struct {
+		version Version
+		release int
+		name    string
+	}{
+		{"MC0.0", 1, "Release 1.1"},
+		{"AC1.2", 2, "Release 1.2"},
+		{"AC1.4", 3, "Release 1.4"},
+		{"AC1.50", 4, "Release 2.0"},
+		{"AC2.10", 5, "Release 2.10"},
+		{"AC1002", 6, "Release 2.5"},
+		{"AC1003", 7, "Release 2.6"},
+		{"AC1004", 8, "Release 9"},
+		{"AC1006", 9, "Release 10"},
+		{"AC1009", 10, "Release 11/12 (LT R1/R2)"},
+		{"AC1012", 11, "Release 13 (LT95)"},
+		{"AC1014", 12, "Release 14, 14.01 (LT97/LT98)"},
+		{"AC1015", 13, "AutoCAD 2000/2000i/2002"},
+		{"AC1018", 14, "AutoCAD 2004-2006"},
+		{"AC1021", 15, "AutoCAD 2007-2009"},
+		{"AC1024", 16, "AutoCAD 2010-2012"},
+		{"AC1027", 17, "AutoCAD 2013-2017"},
+		{"AC1032", 18, "AutoCAD 2018+"},
+		{"AC1040", 0, ""},
+		{"", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.version), func(t *testing.T) {
+			if got := tt.version.Release(); got != tt.release {
+				t.Errorf("Release() = %d, want %d", got, tt.release)
+			}
+			if got := tt.version.ReleaseName(); got != tt.name {
+				t.Errorf("ReleaseName() = %q, want %q", got, tt.name)
+			}
+		})
+	}
+}
+
+func TestVersionReleaseOrdering(t *testing.T) {
+	order := []Version{
+		"MC0.0", "AC1.2", "AC1.4", "AC1.50", "AC2.10", "AC1002", "AC1003",
+		"AC1004", "AC1006", "AC1009", "AC1012", "AC1014", "AC1015", "AC1018",
+		"AC1021", "AC1024", "AC1027", "AC1032",
+	}
+
+	for i := 1; i < len(order); i++ {
+		if order[i-1].Release() >= order[i].Release() {
+			t.Errorf("%s.Release() should be less than %s.Release()", order[i-1], order[i])
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package dxf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"strconv"
+)
+
+// binarySentinel marks the start of a binary DXF file.
+var binarySentinel = []byte("AutoCAD Binary DXF\r\n\x1a\x00")
+
+// binaryReader reads groups from a binary DXF file.
+//
+// Release 12 and earlier binary files use one byte group codes, with 255
+// escaping a two byte code. Later files always use two byte codes. Values
+// are stored in a form determined by their group code.
+type binaryReader struct {
+	r        *bufio.Reader
+	wideCode bool
+}
+
+// newBinaryReader returns a reader for the groups that follow the sentinel
+// at the start of r.
+func newBinaryReader(r *bufio.Reader) (*binaryReader, error) {
+	if _, err := r.Discard(len(binarySentinel)); err != nil {
+		return nil, err
+	}
+
+	// The first group is 0/SECTION, possibly preceded by a 999 comment. With
+	// two byte codes, the second byte is the high byte of the code rather
+	// than the start of the value or an escaped code.
+	start, err := r.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+	wideCode := bytes.Equal(start, []byte{0, 0}) || bytes.Equal(start, []byte{0xE7, 0x03})
+
+	return &binaryReader{r: r, wideCode: wideCode}, nil
+}
+
+func (b *binaryReader) next() (code int, value string, err error) {
+	if b.wideCode {
+		code, err = b.int16()
+	} else {
+		var c byte
+		c, err = b.r.ReadByte()
+		code = int(c)
+		if err == nil && code == 255 {
+			code, err = b.int16()
+		}
+	}
+	if err != nil {
+		return 0, "", err
+	}
+
+	value, err = b.value(code)
+	return code, value, err
+}
+
+// value reads the value of a group with the given code.
+func (b *binaryReader) value(code int) (string, error) {
+	switch {
+	case code >= 310 && code <= 319, code == 1004:
+		return b.chunk()
+	case code >= 10 && code <= 59, code >= 110 && code <= 149,
+		code >= 210 && code <= 239, code >= 460 && code <= 469,
+		code >= 1010 && code <= 1059:
+		v, err := b.fixed(8)
+		return strconv.FormatFloat(math.Float64frombits(v), 'g', -1, 64), err
+	case code >= 160 && code <= 169:
+		v, err := b.fixed(8)
+		return strconv.FormatInt(int64(v), 10), err
+	case code >= 90 && code <= 99, code >= 420 && code <= 429,
+		code >= 440 && code <= 459, code == 1071:
+		v, err := b.fixed(4)
+		return strconv.FormatInt(int64(int32(v)), 10), err
+	case code >= 280 && code <= 299:
+		v, err := b.fixed(1)
+		return strconv.FormatUint(v, 10), err
+	case code >= 60 && code <= 79, code >= 170 && code <= 179,
+		code >= 270 && code <= 279, code >= 370 && code <= 389,
+		code >= 400 && code <= 409, code >= 1060 && code <= 1070:
+		v, err := b.int16()
+		return strconv.Itoa(v), err
+	default:
+		return b.string()
+	}
+}
+
+// int16 reads a little-endian 16-bit integer.
+func (b *binaryReader) int16() (int, error) {
+	v, err := b.fixed(2)
+	return int(int16(v)), err
+}
+
+// fixed reads a little-endian value of the given size in bytes.
+func (b *binaryReader) fixed(size int) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(b.r, buf[:size]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
+
+// string reads a null-terminated string.
+func (b *binaryReader) string() (string, error) {
+	var buf bytes.Buffer
+	for {
+		c, err := b.r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if c == 0 {
+			return buf.String(), nil
+		}
+		if buf.Len() >= maxLine {
+			return "", bufio.ErrTooLong
+		}
+		buf.WriteByte(c)
+	}
+}
+
+// chunk reads a binary chunk, which is preceded by its length.
+func (b *binaryReader) chunk() (string, error) {
+	n, err := b.r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(b.r, data); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
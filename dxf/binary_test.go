@@ -0,0 +1,145 @@
+package dxf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// binaryDXF builds a synthetic binary DXF file.
+type binaryDXF struct {
+	buf      bytes.Buffer
+	wideCode bool
+}
+
+func newBinaryDXF(wideCode bool) *binaryDXF {
+	d := &binaryDXF{wideCode: wideCode}
+	d.buf.Write(binarySentinel)
+	return d
+}
+
+func (d *binaryDXF) code(code int) *binaryDXF {
+	switch {
+	case d.wideCode:
+		d.buf.Write(binary.LittleEndian.AppendUint16(nil, uint16(code)))
+	case code >= 255:
+		d.buf.WriteByte(255)
+		d.buf.Write(binary.LittleEndian.AppendUint16(nil, uint16(code)))
+	default:
+		d.buf.WriteByte(byte(code))
+	}
+	return d
+}
+
+func (d *binaryDXF) str(code int, value string) *binaryDXF {
+	d.code(code)
+	d.buf.WriteString(value)
+	d.buf.WriteByte(0)
+	return d
+}
+
+func (d *binaryDXF) int16(code int, value int16) *binaryDXF {
+	d.code(code)
+	d.buf.Write(binary.LittleEndian.AppendUint16(nil, uint16(value)))
+	return d
+}
+
+func (d *binaryDXF) int32(code int, value int32) *binaryDXF {
+	d.code(code)
+	d.buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(value)))
+	return d
+}
+
+func (d *binaryDXF) double(code int, value float64) *binaryDXF {
+	d.code(code)
+	d.buf.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(value)))
+	return d
+}
+
+func (d *binaryDXF) bytes() []byte {
+	return d.buf.Bytes()
+}
+
+func TestReadHeaderBinary(t *testing.T) {
+	tests := []struct {
+		name string
+		dxf  []byte
+		want *Header
+		err  error
+	}{
+		{
+			name: "R2000",
+			dxf: newBinaryDXF(true).
+				str(0, "SECTION").str(2, "HEADER").
+				str(9, "$ACADVER").str(1, "AC1015").
+				str(9, "$ACADMAINTVER").int16(70, 6).
+				str(9, "$DWGCODEPAGE").str(3, "ANSI_1252").
+				str(9, "$INSBASE").double(10, 0).double(20, 0).double(30, 0).
+				str(0, "ENDSEC").bytes(),
+			want: &Header{Version: "AC1015", MaintenanceRelease: 6, HasMaintenanceRelease: true, Codepage: 30, HasCodepage: true, Binary: true},
+		},
+		{
+			name: "R2018",
+			dxf: newBinaryDXF(true).
+				str(999, "comment").
+				str(0, "SECTION").str(2, "HEADER").
+				str(9, "$ACADVER").str(1, "AC1032").
+				str(9, "$ACADMAINTVER").int32(90, 4).
+				str(9, "$DWGCODEPAGE").str(3, "ANSI_1250").
+				str(0, "ENDSEC").bytes(),
+			want: &Header{Version: "AC1032", MaintenanceRelease: 4, HasMaintenanceRelease: true, Codepage: 28, HasCodepage: true, Binary: true},
+		},
+		{
+			name: "R12",
+			dxf: newBinaryDXF(false).
+				str(0, "SECTION").str(2, "HEADER").
+				str(9, "$ACADVER").str(1, "AC1009").
+				str(9, "$INSBASE").double(10, 1.5).double(20, 2.5).double(30, 0).
+				str(9, "$EXTNAMES").int16(1070, 1).
+				str(0, "ENDSEC").bytes(),
+			want: &Header{Version: "AC1009", Binary: true},
+		},
+		{
+			name: "NoVersion",
+			dxf: newBinaryDXF(true).
+				str(0, "SECTION").str(2, "HEADER").
+				str(9, "$INSBASE").double(10, 0).
+				str(0, "ENDSEC").bytes(),
+			err: ErrNoVersion,
+		},
+		{
+			name: "NoHeaderSection",
+			dxf: newBinaryDXF(true).
+				str(0, "SECTION").str(2, "ENTITIES").
+				str(0, "ENDSEC").bytes(),
+			err: ErrNoVersion,
+		},
+		{
+			name: "Truncated",
+			dxf: newBinaryDXF(true).
+				str(0, "SECTION").str(2, "HEADER").
+				str(9, "$ACADVER").bytes()[:40],
+			err: ErrTruncated,
+		},
+		{
+			name: "SentinelOnly",
+			dxf:  binarySentinel,
+			err:  ErrTruncated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadHeader(bytes.NewReader(tt.dxf))
+			if !errors.Is(err, tt.err) || (tt.err == ErrInvalidDXF && errors.Is(err, ErrTruncated)) {
+				t.Fatalf("ReadHeader() error = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ReadHeader() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
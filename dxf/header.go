@@ -0,0 +1,229 @@
+// Package dxf reads version information from the HEADER section of AutoCAD
+// drawing exchange (DXF) files, in either ASCII or binary form.
+//
+// Versions are reported with the same dwg.Version codes used by DWG files,
+// so DXF and DWG results can be compared and ordered together.
+package dxf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/scjalliance/cadscan/dwg"
+)
+
+var (
+	// ErrInvalidDXF is returned when a file is not a DXF file.
+	ErrInvalidDXF = errors.New("not a DXF file")
+
+	// ErrNoVersion is returned when a DXF file does not declare its version
+	// in a HEADER section.
+	ErrNoVersion = errors.New("DXF file has no $ACADVER header variable")
+
+	// ErrTruncated is returned when a DXF file ends before its HEADER section
+	// is complete. It wraps ErrInvalidDXF.
+	ErrTruncated = fmt.Errorf("%w: truncated header", ErrInvalidDXF)
+)
+
+// Header holds the header variables of a DXF file that describe its
+// version and encoding.
+//
+// Older files don't declare their maintenance release or codepage, so
+// whether each of them was present is recorded alongside it.
+type Header struct {
+	Version               dwg.Version  // $ACADVER
+	MaintenanceRelease    int          // $ACADMAINTVER
+	HasMaintenanceRelease bool         // The file declares $ACADMAINTVER
+	Codepage              dwg.Codepage // $DWGCODEPAGE
+	HasCodepage           bool         // The file declares a recognized $DWGCODEPAGE
+	Binary                bool         // The file is a binary DXF file
+}
+
+// ReadFileHeader attempts to open the file with the given name and read its
+// header variables.
+func ReadFileHeader(name string) (*Header, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadHeader(f)
+}
+
+// ReadHeader reads the header variables of the DXF file in r. Binary DXF
+// files are recognized by their content.
+//
+// Reading stops at the end of the HEADER section, or sooner, so only the
+// start of the file is consumed.
+func ReadHeader(r io.Reader) (*Header, error) {
+	br := bufio.NewReader(r)
+
+	start, err := br.Peek(len(binarySentinel))
+	if err != nil || !bytes.Equal(start, binarySentinel) {
+		return readHeader(newTextReader(br))
+	}
+
+	b, err := newBinaryReader(br)
+	if err != nil {
+		return nil, truncated(err)
+	}
+
+	h, err := readHeader(b)
+	if h != nil {
+		h.Binary = true
+	}
+	return h, err
+}
+
+// groupReader reads group code and value pairs from a DXF file.
+type groupReader interface {
+	// next returns the next group code and its value as a string.
+	next() (code int, value string, err error)
+}
+
+// readHeader reads the header variables from the groups in r.
+func readHeader(r groupReader) (*Header, error) {
+	// The file must begin with a section, optionally preceded by comments
+	for {
+		code, value, err := r.next()
+		if err != nil {
+			return nil, invalid(err)
+		}
+		if code == 999 {
+			continue
+		}
+		if code != 0 || value != "SECTION" {
+			return nil, ErrInvalidDXF
+		}
+		break
+	}
+
+	code, value, err := r.next()
+	if err != nil {
+		return nil, truncated(err)
+	}
+	if code != 2 {
+		return nil, ErrInvalidDXF
+	}
+	if value != "HEADER" {
+		// Files without a HEADER section are valid but don't say which
+		// version they are
+		return nil, ErrNoVersion
+	}
+
+	h := new(Header)
+
+	var variable string
+	for {
+		code, value, err := r.next()
+		if err != nil {
+			return nil, truncated(err)
+		}
+
+		switch code {
+		case 0:
+			if h.Version == "" {
+				return nil, ErrNoVersion
+			}
+			return h, nil
+		case 9:
+			variable = value
+			continue
+		}
+
+		switch variable {
+		case "$ACADVER":
+			h.Version = dwg.Version(value)
+		case "$ACADMAINTVER":
+			if n, err := strconv.Atoi(value); err == nil {
+				h.MaintenanceRelease, h.HasMaintenanceRelease = n, true
+			}
+		case "$DWGCODEPAGE":
+			// AutoCAD writes $DWGCODEPAGE after $ACADVER and $ACADMAINTVER,
+			// so there's no need to read any further
+			h.Codepage, h.HasCodepage = dwg.ParseCodepage(value)
+			if h.Version != "" {
+				return h, nil
+			}
+		}
+	}
+}
+
+// invalid translates an error encountered while reading the groups of a
+// file that may not be a DXF file at all.
+func invalid(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF || err == bufio.ErrTooLong || errors.Is(err, strconv.ErrSyntax) || errors.Is(err, strconv.ErrRange) {
+		return ErrInvalidDXF
+	}
+	return err
+}
+
+// truncated translates an error encountered while reading the groups of a
+// file that has already been recognized as a DXF file, in which running out
+// of data means the file is incomplete.
+func truncated(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrTruncated
+	}
+	return invalid(err)
+}
+
+// maxLine is the longest line accepted in an ASCII DXF file. AutoCAD limits
+// values to 2049 characters.
+const maxLine = 4096
+
+// textReader reads groups from an ASCII DXF file, in which each group code
+// and each value is written on a line of its own.
+type textReader struct {
+	scanner *bufio.Scanner
+	first   bool
+}
+
+func newTextReader(r io.Reader) *textReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 512), maxLine)
+	return &textReader{scanner: scanner, first: true}
+}
+
+func (t *textReader) line() (string, error) {
+	if !t.scanner.Scan() {
+		if err := t.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.ErrUnexpectedEOF
+	}
+
+	line := t.scanner.Bytes()
+	if t.first {
+		line = bytes.TrimPrefix(line, []byte("\xEF\xBB\xBF")) // UTF-8 byte order mark
+		t.first = false
+	}
+
+	return strings.TrimRight(string(line), "\r"), nil
+}
+
+func (t *textReader) next() (code int, value string, err error) {
+	line, err := t.line()
+	if err != nil {
+		return 0, "", err
+	}
+
+	code, err = strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		return 0, "", err
+	}
+
+	value, err = t.line()
+	if err != nil {
+		return 0, "", err
+	}
+
+	return code, strings.TrimSpace(value), nil
+}
@@ -0,0 +1,75 @@
+package dxf
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadHeaderText(t *testing.T) {
+	tests := []struct {
+		name string
+		dxf  string
+		want *Header
+		err  error
+	}{
+		{
+			name: "R2000",
+			dxf:  "  0\r\nSECTION\r\n  2\r\nHEADER\r\n  9\r\n$ACADVER\r\n  1\r\nAC1015\r\n  9\r\n$ACADMAINTVER\r\n 70\r\n     6\r\n  9\r\n$DWGCODEPAGE\r\n  3\r\nANSI_1252\r\n  0\r\nENDSEC\r\n",
+			want: &Header{Version: "AC1015", MaintenanceRelease: 6, HasMaintenanceRelease: true, Codepage: 30, HasCodepage: true},
+		},
+		{
+			name: "R2018",
+			dxf:  "\xEF\xBB\xBF999\ncomment\n0\nSECTION\n2\nHEADER\n9\n$ACADVER\n1\nAC1032\n9\n$ACADMAINTVER\n90\n4\n9\n$DWGCODEPAGE\n3\nANSI_1251\n9\n$INSBASE\n10\n0.0\n20\n0.0\n30\n0.0\n0\nENDSEC\n0\nEOF\n",
+			want: &Header{Version: "AC1032", MaintenanceRelease: 4, HasMaintenanceRelease: true, Codepage: 29, HasCodepage: true},
+		},
+		{
+			name: "R12",
+			dxf:  "0\nSECTION\n2\nHEADER\n9\n$ACADVER\n1\nAC1009\n9\n$INSBASE\n10\n0.0\n0\nENDSEC\n",
+			want: &Header{Version: "AC1009"},
+		},
+		{
+			name: "NoHeaderSection",
+			dxf:  "0\nSECTION\n2\nENTITIES\n0\nLINE\n0\nENDSEC\n0\nEOF\n",
+			err:  ErrNoVersion,
+		},
+		{
+			name: "NoVersion",
+			dxf:  "0\nSECTION\n2\nHEADER\n9\n$INSBASE\n10\n0.0\n0\nENDSEC\n",
+			err:  ErrNoVersion,
+		},
+		{
+			name: "Truncated",
+			dxf:  "0\nSECTION\n2\nHEADER\n9\n$ACADVER\n1\nAC1015\n",
+			err:  ErrTruncated,
+		},
+		{
+			name: "Empty",
+			dxf:  "",
+			err:  ErrInvalidDXF,
+		},
+		{
+			name: "Text",
+			dxf:  "hello\nworld\n",
+			err:  ErrInvalidDXF,
+		},
+		{
+			name: "LongLine",
+			dxf:  strings.Repeat("x", maxLine+1),
+			err:  ErrInvalidDXF,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadHeader(strings.NewReader(tt.dxf))
+			if !errors.Is(err, tt.err) || (tt.err == ErrInvalidDXF && errors.Is(err, ErrTruncated)) {
+				t.Fatalf("ReadHeader() error = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ReadHeader() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/scjalliance/cadscan/dwg"
+	"github.com/scjalliance/cadscan/dxf"
+)
+
+// Reason describes why a file or directory could not be assessed.
+type Reason int
+
+// Reasons that a file or directory could not be assessed.
+const (
+	ReasonNone          Reason = iota // The file was assessed successfully
+	ReasonOpen                        // The file or directory could not be opened
+	ReasonPermission                  // Access to the file or directory was denied
+	ReasonTruncated                   // The file ended before its header was complete
+	ReasonNotDrawing                  // The file does not have a drawing header
+	ReasonUnknownHeader               // The file has a drawing header that isn't recognized
+	ReasonTimeout                     // Reading the file took longer than the scan allowed
+	ReasonRead                        // The file was opened but could not be read
+)
+
+// reasonCount is the number of defined reasons, including ReasonNone.
+const reasonCount = int(ReasonRead) + 1
+
+// String returns a description of the reason.
+func (r Reason) String() string {
+	switch r {
+	case ReasonNone:
+		return ""
+	case ReasonOpen:
+		return "open error"
+	case ReasonPermission:
+		return "permission denied"
+	case ReasonTruncated:
+		return "truncated header"
+	case ReasonNotDrawing:
+		return "not a drawing"
+	case ReasonUnknownHeader:
+		return "unknown header code"
+	case ReasonTimeout:
+		return "timed out"
+	case ReasonRead:
+		return "read error"
+	default:
+		return "unknown reason"
+	}
+}
+
+// classifyError returns the reason that err prevented a file or directory
+// from being assessed.
+func classifyError(err error) Reason {
+	switch {
+	case err == nil:
+		return ReasonNone
+	case errors.Is(err, fs.ErrPermission):
+		return ReasonPermission
+	case errors.Is(err, dwg.ErrTruncated), errors.Is(err, dxf.ErrTruncated):
+		return ReasonTruncated
+	case errors.Is(err, dwg.ErrInvalidDrawing), errors.Is(err, dxf.ErrInvalidDXF):
+		return ReasonNotDrawing
+	case errors.Is(err, dxf.ErrNoVersion):
+		return ReasonUnknownHeader
+	default:
+		return ReasonOpen
+	}
+}
+
+// classifyReadError returns the reason that err prevented a file that was
+// opened successfully from being assessed. Errors that classifyError would
+// put down to opening the file are failures to read it.
+func classifyReadError(err error) Reason {
+	if reason := classifyError(err); reason != ReasonOpen {
+		return reason
+	}
+	return ReasonRead
+}
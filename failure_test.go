@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/scjalliance/cadscan/dwg"
+	"github.com/scjalliance/cadscan/dxf"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Reason
+	}{
+		{"None", nil, ReasonNone},
+		{"Open", &fs.PathError{Op: "open", Path: "site.dwg", Err: fs.ErrNotExist}, ReasonOpen},
+		{"Unknown", errors.New("network name is no longer available"), ReasonOpen},
+		{"Permission", &fs.PathError{Op: "open", Path: "site.dwg", Err: fs.ErrPermission}, ReasonPermission},
+		{"Truncated", dwg.ErrTruncated, ReasonTruncated},
+		{"NotDrawing", dwg.ErrInvalidDrawing, ReasonNotDrawing},
+		{"TruncatedDXF", dxf.ErrTruncated, ReasonTruncated},
+		{"NotDXF", fmt.Errorf("site.dxf: %w", dxf.ErrInvalidDXF), ReasonNotDrawing},
+		{"UnknownCode", dxf.ErrNoVersion, ReasonUnknownHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(tt.err); got != tt.want {
+				t.Errorf("classifyError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyReadError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Reason
+	}{
+		{"Read", &fs.PathError{Op: "read", Path: "site.dwg", Err: errors.New("network name is no longer available")}, ReasonRead},
+		{"Truncated", dwg.ErrTruncated, ReasonTruncated},
+		{"NotDrawing", dwg.ErrInvalidDrawing, ReasonNotDrawing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyReadError(tt.err); got != tt.want {
+				t.Errorf("classifyReadError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadFileReasons(t *testing.T) {
+	// A directory can be opened like a file, but reading it fails
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"truncated.dwg": "AC10",
+		"notes.dwg":     "Meeting notes",
+		"future.dwg":    "AC1099",
+		"truncated.dxf": "0\nSECTION\n2\nHEADER\n9\n$ACADVER\n",
+		"notes.dxf":     "Meeting notes\n",
+		"folder.dwg/":   "",
+	})
+
+	tests := []struct {
+		name  string
+		want  Reason
+		fresh bool
+	}{
+		{"missing.dwg", ReasonOpen, false},
+		{"folder.dwg", ReasonRead, true},
+		{"truncated.dwg", ReasonTruncated, true},
+		{"notes.dwg", ReasonNotDrawing, true},
+		{"future.dwg", ReasonUnknownHeader, true},
+		{"truncated.dxf", ReasonTruncated, true},
+		{"notes.dxf", ReasonNotDrawing, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := readFile(filepath.Join(dir, tt.name))
+			if file.Reason != tt.want {
+				t.Fatalf("readFile() reason = %v (%v), want %v", file.Reason, file.Err, tt.want)
+			}
+			var totals Totals
+			totals.record(file)
+			if fresh := totals.FreshReads == 1; fresh != tt.fresh {
+				t.Errorf("counted as a fresh read = %v, want %v", fresh, tt.fresh)
+			}
+		})
+	}
+}
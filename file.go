@@ -1,7 +1,259 @@
 package main
 
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scjalliance/cadscan/dwg"
+	"github.com/scjalliance/cadscan/dxf"
+)
+
+// Format identifies the container format of a drawing file.
+type Format int
+
+// Drawing container formats.
+const (
+	FormatUnknown   Format = iota
+	FormatDWG              // AutoCAD drawing
+	FormatDXF              // ASCII drawing exchange file
+	FormatBinaryDXF        // Binary drawing exchange file
+)
+
+// String returns the name of the format.
+func (f Format) String() string {
+	switch f {
+	case FormatDWG:
+		return "DWG"
+	case FormatDXF:
+		return "DXF"
+	case FormatBinaryDXF:
+		return "Binary DXF"
+	default:
+		return ""
+	}
+}
+
 // File represents a scanned file.
+//
+// Files and directories that could not be assessed are also represented by
+// a File, with a Reason other than ReasonNone.
 type File struct {
-	Path    string
-	Version DrawingVersion
+	Path                 string
+	Root                 string    // The root of the scan that found the file
+	Size                 int64     // Size of the file in bytes
+	ModTime              time.Time // Modification time of the file
+	Format               Format
+	Version              DrawingVersion
+	Header               *DrawingHeader     // The drawing's file header, if it was read
+	NoMaintenanceRelease bool               // The DXF header doesn't declare a maintenance release
+	NoCodepage           bool               // The DXF header doesn't declare a codepage
+	Properties           *DrawingProperties // The drawing's properties, if it has readable ones
+	App                  *DrawingAppInfo    // The application that last saved the drawing, if recorded
+	Dir                  bool               // The failure is of a directory rather than a file
+	Reason               Reason             // Why the file could not be assessed, if it couldn't
+	Err                  error              // The error that prevented assessment, if any
+	Cached               bool               // The result was taken from the scan cache
+	ReadTime             time.Duration      // How long reading the file took, if it was read
+	BytesRead            int64              // Number of bytes read from the file, if it was read
+}
+
+// Failed reports whether the file could not be assessed.
+func (f File) Failed() bool {
+	return f.Reason != ReasonNone
+}
+
+// Codepage returns the name of the drawing's codepage, or an empty string if
+// the drawing's header doesn't record one.
+func (f File) Codepage() string {
+	if !f.hasFileHeader() || f.NoCodepage {
+		return ""
+	}
+	return f.Header.Codepage.String()
+}
+
+// MaintenanceRelease returns the drawing's maintenance release number, or an
+// empty string if the drawing's header doesn't record one.
+func (f File) MaintenanceRelease() string {
+	if !f.hasFileHeader() || f.NoMaintenanceRelease {
+		return ""
+	}
+	return strconv.Itoa(f.Header.MaintenanceRelease)
+}
+
+// CustomProperties returns the drawing's custom properties formatted as a
+// list of name=value pairs separated by semicolons.
+func (f File) CustomProperties() string {
+	if f.Properties == nil {
+		return ""
+	}
+	pairs := make([]string, len(f.Properties.Custom))
+	for i, p := range f.Properties.Custom {
+		pairs[i] = p.Name + "=" + p.Value
+	}
+	return strings.Join(pairs, "; ")
+}
+
+// Product returns the name of the product that last saved the drawing, or an
+// empty string if it isn't recorded.
+func (f File) Product() string {
+	if f.App == nil {
+		return ""
+	}
+	return f.App.ProductName
+}
+
+// ProductVersion returns the release year or version of the product that
+// last saved the drawing. When the drawing has no readable application
+// information, the range of releases that write the application's native
+// format is returned instead, as recorded in the file header.
+func (f File) ProductVersion() string {
+	if f.App != nil {
+		if year := f.App.ProductYear(); year != 0 {
+			return strconv.Itoa(year)
+		}
+		if f.App.Version != "" {
+			return f.App.Version
+		}
+	}
+	if f.Header != nil && f.Format == FormatDWG {
+		return f.Header.AppFormat().ReleaseName()
+	}
+	return ""
+}
+
+// Trusted returns "Yes" if the drawing was last saved as a TrustedDWG and
+// "No" if it wasn't. An empty string is returned if the drawing doesn't
+// record it, which is the case for drawings older than AutoCAD 2007.
+func (f File) Trusted() string {
+	switch {
+	case f.App == nil || f.Version.Release() < DrawingVersion("AC1021").Release():
+		return ""
+	case f.App.Trusted():
+		return "Yes"
+	default:
+		return "No"
+	}
+}
+
+// maintenanceRelease returns the maintenance release of file for sorting and
+// filtering, or -1 if it doesn't have one.
+func maintenanceRelease(file File) int {
+	if file.MaintenanceRelease() == "" {
+		return -1
+	}
+	return file.Header.MaintenanceRelease
+}
+
+// hasFileHeader reports whether f has a header that records more than the
+// drawing version. That includes every DXF header and the file headers of
+// Release 13 and later DWG files.
+func (f File) hasFileHeader() bool {
+	if f.Header == nil {
+		return false
+	}
+	return f.Format != FormatDWG || f.Header.Version.Release() >= DrawingVersion("AC1012").Release()
+}
+
+// readFile reads the header of the drawing at path, along with its
+// properties if it has them.
+//
+// Files that can't be opened are told apart from files that fail once
+// they're being read, since only the latter have been read from.
+func readFile(path string) File {
+	file := File{Path: path, Format: FormatDWG}
+	if strings.EqualFold(filepath.Ext(path), ".dxf") {
+		file.Format = FormatDXF
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		file.Reason, file.Err = classifyError(err), err
+		return file
+	}
+	defer f.Close()
+	r := &countingReader{f: f}
+
+	var header *DrawingHeader
+	switch file.Format {
+	case FormatDXF:
+		header, err = readDXFHeader(r, &file)
+	default:
+		header, err = dwg.ReadHeader(r)
+	}
+
+	switch {
+	case err != nil:
+		file.Reason, file.Err = classifyReadError(err), err
+	case header.Version.Release() == 0:
+		file.Version = header.Version
+		file.Reason, file.Err = ReasonUnknownHeader, fmt.Errorf("unrecognized drawing header %q", header.Version)
+	default:
+		file.Version, file.Header = header.Version, header
+		if file.Format == FormatDWG && header.Version.Release() >= DrawingVersion("AC1018").Release() {
+			readDrawingSections(r, &file)
+		}
+	}
+
+	file.BytesRead = r.n
+	return file
+}
+
+// countingReader counts the bytes read from a file, so that a scan can
+// report how much it actually read rather than the sizes of the files.
+type countingReader struct {
+	f *os.File
+	n int64
+}
+
+func (c *countingReader) Read(b []byte) (int, error) {
+	n, err := c.f.Read(b)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadAt(b []byte, offset int64) (int, error) {
+	n, err := c.f.ReadAt(b, offset)
+	c.n += int64(n)
+	return n, err
+}
+
+// readDrawingSections reads the properties and application information of
+// the AutoCAD 2004 or later drawing in r into file. Missing or unreadable
+// sections don't prevent assessment, so they just leave their fields nil.
+// The drawing's section map is read once and shared by both.
+func readDrawingSections(r io.ReaderAt, file *File) {
+	sections, err := dwg.OpenSections(r)
+	if err != nil {
+		return
+	}
+	file.Properties, _ = sections.SummaryInfo()
+	file.App, _ = sections.AppInfo()
+}
+
+// readDXFHeader reads the header variables of the DXF file in r and returns
+// them as a drawing header. If the file turns out to be a binary DXF
+// file, file's format is updated to say so, and file records which of the
+// header variables the file doesn't declare.
+func readDXFHeader(r io.Reader, file *File) (*DrawingHeader, error) {
+	h, err := dxf.ReadHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.Binary {
+		file.Format = FormatBinaryDXF
+	}
+	file.NoMaintenanceRelease = !h.HasMaintenanceRelease
+	file.NoCodepage = !h.HasCodepage
+
+	return &DrawingHeader{
+		Version:            h.Version,
+		MaintenanceRelease: h.MaintenanceRelease,
+		Codepage:           h.Codepage,
+	}, nil
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReadFileDXFHeader(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"r2000.dxf": "0\nSECTION\n2\nHEADER\n9\n$ACADVER\n1\nAC1015\n9\n$ACADMAINTVER\n70\n0\n9\n$DWGCODEPAGE\n3\nANSI_1252\n0\nENDSEC\n",
+		"r12.dxf":   "0\nSECTION\n2\nHEADER\n9\n$ACADVER\n1\nAC1009\n9\n$INSBASE\n10\n0.0\n0\nENDSEC\n",
+	})
+
+	tests := []struct {
+		name        string
+		maintenance string
+		codepage    string
+	}{
+		{"r2000.dxf", "0", "ANSI_1252"},
+		{"r12.dxf", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := readFile(filepath.Join(dir, tt.name))
+			if file.Failed() {
+				t.Fatalf("readFile() failed: %v", file.Err)
+			}
+			if got := file.MaintenanceRelease(); got != tt.maintenance {
+				t.Errorf("MaintenanceRelease() = %q, want %q", got, tt.maintenance)
+			}
+			if got := file.Codepage(); got != tt.codepage {
+				t.Errorf("Codepage() = %q, want %q", got, tt.codepage)
+			}
+			if got := maintenanceRelease(file); tt.maintenance == "" && got != -1 {
+				t.Errorf("maintenanceRelease() = %d, want -1", got)
+			}
+		})
+	}
+}
+
+func TestReadFileBytesRead(t *testing.T) {
+	// Only the header at the start of a large drawing is read
+	dir := t.TempDir()
+	data := make([]byte, 1<<20)
+	copy(data, "AC1015")
+	writeTree(t, dir, map[string]string{"site.dwg": string(data)})
+	path := filepath.Join(dir, "site.dwg")
+
+	file := readFile(path)
+	if file.Failed() {
+		t.Fatalf("readFile() failed: %v", file.Err)
+	}
+	if file.BytesRead == 0 || file.BytesRead >= int64(len(data)) {
+		t.Errorf("readFile() BytesRead = %d, want more than 0 and less than the file's %d bytes", file.BytesRead, len(data))
+	}
+}
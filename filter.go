@@ -0,0 +1,216 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/Knetic/govaluate.v3"
+)
+
+// Filter selects scan results with an expression, such as:
+//
+//	release < 13 && path =~ "\\\\Projects\\\\2019"
+//	header == "AC1032" && size > 50MB
+//
+// Expressions use the govaluate language. They can refer to the fields
+// listed in filterFields, and sizes can be written with the units KB, MB, GB
+// and TB, which are multiples of 1024. Dates, such as modified > "2023-01-01",
+// are compared as times.
+type Filter struct {
+	text string
+	expr *govaluate.EvaluableExpression
+}
+
+// filterFields are the fields of a file that filter expressions can refer
+// to. Numbers are float64 values, as govaluate expects.
+var filterFields = map[string]func(File) interface{}{
+	"path":     text(func(f File) string { return f.Path }),
+	"name":     text(func(f File) string { return filepath.Base(f.Path) }),
+	"dir":      text(func(f File) string { return filepath.Dir(f.Path) }),
+	"ext":      text(func(f File) string { return strings.ToLower(filepath.Ext(f.Path)) }),
+	"format":   text(func(f File) string { return f.Format.String() }),
+	"header":   text(func(f File) string { return f.Version.String() }),
+	"version":  text(func(f File) string { return f.Version.ReleaseName() }),
+	"release":  func(f File) interface{} { return float64(f.Version.Release()) },
+	"maint":    func(f File) interface{} { return float64(maintenanceRelease(f)) },
+	"codepage": text(File.Codepage),
+	"size":     func(f File) interface{} { return float64(f.Size) },
+	"modified": func(f File) interface{} { return float64(f.ModTime.Unix()) },
+	"cached":   func(f File) interface{} { return f.Cached },
+	"failed":   func(f File) interface{} { return f.Failed() },
+	"reason":   text(func(f File) string { return f.Reason.String() }),
+	"error": text(func(f File) string {
+		if f.Err == nil {
+			return ""
+		}
+		return f.Err.Error()
+	}),
+	"title":          text(property(func(p *DrawingProperties) string { return p.Title })),
+	"subject":        text(property(func(p *DrawingProperties) string { return p.Subject })),
+	"author":         text(property(func(p *DrawingProperties) string { return p.Author })),
+	"keywords":       text(property(func(p *DrawingProperties) string { return p.Keywords })),
+	"comments":       text(property(func(p *DrawingProperties) string { return p.Comments })),
+	"lastSavedBy":    text(property(func(p *DrawingProperties) string { return p.LastSavedBy })),
+	"revision":       text(property(func(p *DrawingProperties) string { return p.RevisionNumber })),
+	"custom":         text(File.CustomProperties),
+	"product":        text(File.Product),
+	"productVersion": text(File.ProductVersion),
+	"trusted":        func(f File) interface{} { return f.Trusted() == "Yes" },
+}
+
+// text adapts a function returning a string for use in filterFields.
+func text(fn func(File) string) func(File) interface{} {
+	return func(f File) interface{} { return fn(f) }
+}
+
+// FilterFields returns the names of the fields that filters can refer to,
+// in alphabetical order.
+func FilterFields() []string {
+	names := make([]string, 0, len(filterFields))
+	for name := range filterFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseFilter parses a filter expression.
+func ParseFilter(text string) (*Filter, error) {
+	expr, err := govaluate.NewEvaluableExpression(expandSizes(text))
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %v", err)
+	}
+
+	for _, name := range expr.Vars() {
+		if _, ok := filterFields[name]; !ok {
+			return nil, fmt.Errorf("invalid filter: unknown field \"%s\"", name)
+		}
+	}
+
+	return &Filter{text: text, expr: expr}, nil
+}
+
+// String returns the text of the filter expression.
+func (f *Filter) String() string {
+	return f.text
+}
+
+// errFilterResult is returned when a filter expression doesn't evaluate to
+// true or false.
+var errFilterResult = errors.New("filter does not evaluate to true or false")
+
+// Match reports whether file satisfies the filter.
+func (f *Filter) Match(file File) (bool, error) {
+	result, err := f.expr.Eval(fileParameters{file})
+	if err != nil {
+		return false, err
+	}
+	match, ok := result.(bool)
+	if !ok {
+		return false, errFilterResult
+	}
+	return match, nil
+}
+
+// fileParameters provides the fields of a file to a filter expression.
+type fileParameters struct {
+	file File
+}
+
+func (p fileParameters) Get(name string) (interface{}, error) {
+	field, ok := filterFields[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown field \"%s\"", name)
+	}
+	return field(p.file), nil
+}
+
+// sizeUnits are the multipliers of the units sizes can be written with.
+var sizeUnits = map[string]float64{
+	"KB": 1 << 10,
+	"MB": 1 << 20,
+	"GB": 1 << 30,
+	"TB": 1 << 40,
+}
+
+// sizePattern matches a number followed by a size unit.
+var sizePattern = regexp.MustCompile(`(?i)\b(\d+(?:\.\d+)?)\s*(KB|MB|GB|TB)\b`)
+
+// expandSizes replaces sizes with units in an expression, such as 50MB,
+// with the equivalent number of bytes. Quoted strings are left alone.
+func expandSizes(expr string) string {
+	var b strings.Builder
+	for len(expr) > 0 {
+		// Copy quoted strings as they are
+		if quote := expr[0]; quote == '"' || quote == '\'' {
+			end := 1
+			for end < len(expr) && expr[end] != quote {
+				if expr[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end < len(expr) {
+				end++
+			}
+			if end > len(expr) {
+				end = len(expr)
+			}
+			b.WriteString(expr[:end])
+			expr = expr[end:]
+			continue
+		}
+
+		end := strings.IndexAny(expr, `"'`)
+		if end < 0 {
+			end = len(expr)
+		}
+		b.WriteString(sizePattern.ReplaceAllStringFunc(expr[:end], func(size string) string {
+			m := sizePattern.FindStringSubmatch(size)
+			n, err := strconv.ParseFloat(m[1], 64)
+			if err != nil {
+				return size
+			}
+			return strconv.FormatFloat(n*sizeUnits[strings.ToUpper(m[2])], 'f', -1, 64)
+		}))
+		expr = expr[end:]
+	}
+	return b.String()
+}
+
+// filterSink is a Sink that relays only the results that satisfy a filter
+// to another sink.
+type filterSink struct {
+	Sink
+	filter *Filter
+	err    error // The first error encountered while evaluating the filter
+}
+
+// Append relays the results that satisfy the filter. Results that the filter
+// can't be evaluated for are left out.
+func (s *filterSink) Append(files ...File) {
+	var matched []File
+	for _, file := range files {
+		ok, err := s.filter.Match(file)
+		if err != nil && s.err == nil {
+			s.err = fmt.Errorf("filter could not be evaluated for %s: %v", file.Path, err)
+		}
+		if ok {
+			matched = append(matched, file)
+		}
+	}
+	if len(matched) > 0 {
+		s.Sink.Append(matched...)
+	}
+}
+
+// Unbatched reports whether the filtered sink wants results delivered one at
+// a time.
+func (s *filterSink) Unbatched() bool {
+	return unbatched(s.Sink)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterMatch(t *testing.T) {
+	file := File{
+		Path:       filepath.Join("srv", "Projects", "2019", "Site Plan.DWG"),
+		Size:       60 << 20,
+		ModTime:    time.Date(2023, 6, 1, 12, 0, 0, 0, time.Local),
+		Format:     FormatDWG,
+		Version:    "AC1015",
+		Properties: &DrawingProperties{Author: "jsmith"},
+	}
+
+	tests := []struct {
+		expr  string
+		match bool
+	}{
+		{`release < 14`, true},
+		{`release >= 14`, false},
+		{`header == "AC1015" && size > 50MB`, true},
+		{`size > 0.5GB`, false},
+		{`ext == ".dwg"`, true},
+		{`name =~ "^Site"`, true},
+		{`path =~ "2019"`, true},
+		{`author == "jsmith" && !failed`, true},
+		{`modified > "2023-01-01"`, true},
+		{`modified < "2023-01-01"`, false},
+		{`title == "50MB"`, false}, // Sizes within strings aren't expanded
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			filter, err := ParseFilter(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseFilter() error = %v", err)
+			}
+			got, err := filter.Match(file)
+			if err != nil {
+				t.Fatalf("Match() error = %v", err)
+			}
+			if got != tt.match {
+				t.Errorf("Match() = %v, want %v", got, tt.match)
+			}
+		})
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{`release <`, "invalid filter"},
+		{`colour == "red"`, `unknown field "colour"`},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseFilter(tt.expr); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("ParseFilter(%q) error = %v, want one containing %q", tt.expr, err, tt.err)
+		}
+	}
+}
+
+func TestFilterResult(t *testing.T) {
+	filter, err := ParseFilter(`release + 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := filter.Match(File{Version: "AC1015"}); err != errFilterResult {
+		t.Errorf("Match() error = %v, want %v", err, errFilterResult)
+	}
+}
+
+func TestExpandSizes(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`size > 1KB`, `size > 1024`},
+		{`size > 1.5 mb`, `size > 1572864`},
+		{`size < 2GB && size > 1TB`, `size < 2147483648 && size > 1099511627776`},
+		{`name == "10MB.dwg" || size > 10MB`, `name == "10MB.dwg" || size > 10485760`},
+		{`name == 'it\'s 1KB'`, `name == 'it\'s 1KB'`},
+		{`name == "unterminated 1KB`, `name == "unterminated 1KB`},
+	}
+
+	for _, tt := range tests {
+		if got := expandSizes(tt.expr); got != tt.want {
+			t.Errorf("expandSizes(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestFilterSink(t *testing.T) {
+	filter, err := ParseFilter(`release >= 14`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter := &countingSink{}
+	sink := &filterSink{Sink: counter, filter: filter}
+	sink.Append(File{Version: "AC1015"}, File{Version: "AC1018"}, File{Version: "AC1032"})
+	if counter.files != 2 {
+		t.Errorf("relayed %d results, want 2", counter.files)
+	}
+}
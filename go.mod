@@ -5,11 +5,13 @@ go 1.20
 require (
 	github.com/josephspurrier/goversioninfo v1.4.0
 	github.com/lxn/walk v0.0.0-20210112085537-c389da54e794
+	golang.org/x/image v0.10.0
+	golang.org/x/text v0.11.0
+	gopkg.in/Knetic/govaluate.v3 v3.0.0
 )
 
 require (
 	github.com/akavel/rsrc v0.10.2 // indirect
 	github.com/lxn/win v0.0.0-20210218163916-a377121e959e // indirect
 	golang.org/x/sys v0.6.0 // indirect
-	gopkg.in/Knetic/govaluate.v3 v3.0.0 // indirect
 )
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// runGUI reports that the graphical interface is unavailable on this
+// platform.
+func runGUI() int {
+	fmt.Fprintf(os.Stderr, "The graphical interface is only available on Windows.\n\n")
+	usage(os.Stderr)
+	return 2
+}
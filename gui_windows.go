@@ -0,0 +1,48 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// runGUI displays the scanning window and blocks until it is closed.
+func runGUI() int {
+	scanModel := &ScanModel{}
+
+	// The default rules are used if the user's rules can't be loaded
+	rules := DefaultRules()
+	if path, err := DefaultRulesPath(); err == nil {
+		if err := rules.Load(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("Failed to load the rules: %v\n", err)
+			rules = DefaultRules()
+		}
+	}
+
+	treeModel, err := NewDirectoryTreeModel(rules)
+	if err != nil {
+		fmt.Printf("Failed to prepare directory tree: %v\n", err)
+		return 1
+	}
+
+	// The scanner works without a cache if one can't be opened
+	var cache *Cache
+	if path, err := DefaultCachePath(); err == nil {
+		cache, _ = OpenCache(path)
+	}
+
+	scanner := NewScanner(DefaultWorkers)
+	defer scanner.Stop()
+
+	window, err := NewScanWindow(scanner, cache, rules, treeModel, scanModel)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	window.Run()
+
+	return 0
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// jsonFile is the JSON representation of a scanned file.
+type jsonFile struct {
+	Path               string          `json:"path"`
+	Root               string          `json:"root,omitempty"`
+	Dir                bool            `json:"dir,omitempty"`
+	Size               int64           `json:"size,omitempty"`
+	ModTime            *time.Time      `json:"modTime,omitempty"`
+	Format             string          `json:"format,omitempty"`
+	Header             string          `json:"header,omitempty"`
+	Version            string          `json:"version,omitempty"`
+	Release            int             `json:"release,omitempty"`
+	MaintenanceRelease string          `json:"maintenanceRelease,omitempty"`
+	Codepage           string          `json:"codepage,omitempty"`
+	Properties         *jsonProperties `json:"properties,omitempty"`
+	Product            string          `json:"product,omitempty"`
+	ProductVersion     string          `json:"productVersion,omitempty"`
+	Trusted            *bool           `json:"trusted,omitempty"`
+	Cached             bool            `json:"cached,omitempty"`
+	Reason             string          `json:"reason,omitempty"`
+	Error              string          `json:"error,omitempty"`
+}
+
+// jsonProperties is the JSON representation of a drawing's properties.
+type jsonProperties struct {
+	Title            string            `json:"title,omitempty"`
+	Subject          string            `json:"subject,omitempty"`
+	Author           string            `json:"author,omitempty"`
+	Keywords         string            `json:"keywords,omitempty"`
+	Comments         string            `json:"comments,omitempty"`
+	LastSavedBy      string            `json:"lastSavedBy,omitempty"`
+	RevisionNumber   string            `json:"revisionNumber,omitempty"`
+	HyperlinkBase    string            `json:"hyperlinkBase,omitempty"`
+	TotalEditingTime string            `json:"totalEditingTime,omitempty"`
+	Created          *time.Time        `json:"created,omitempty"`
+	Modified         *time.Time        `json:"modified,omitempty"`
+	Custom           map[string]string `json:"custom,omitempty"`
+}
+
+// newJSONFile returns the JSON representation of file.
+func newJSONFile(file File) jsonFile {
+	j := jsonFile{
+		Path:               file.Path,
+		Root:               file.Root,
+		Dir:                file.Dir,
+		Size:               file.Size,
+		Format:             file.Format.String(),
+		Header:             file.Version.String(),
+		Version:            file.Version.ReleaseName(),
+		Release:            file.Version.Release(),
+		MaintenanceRelease: file.MaintenanceRelease(),
+		Codepage:           file.Codepage(),
+		Product:            file.Product(),
+		ProductVersion:     file.ProductVersion(),
+		Cached:             file.Cached,
+		Reason:             file.Reason.String(),
+	}
+	if p := file.Properties; p != nil {
+		j.Properties = &jsonProperties{
+			Title:            p.Title,
+			Subject:          p.Subject,
+			Author:           p.Author,
+			Keywords:         p.Keywords,
+			Comments:         p.Comments,
+			LastSavedBy:      p.LastSavedBy,
+			RevisionNumber:   p.RevisionNumber,
+			HyperlinkBase:    p.HyperlinkBase,
+			TotalEditingTime: p.TotalEditingTime.String(),
+			Custom:           make(map[string]string, len(p.Custom)),
+		}
+		if !p.Created.IsZero() {
+			j.Properties.Created = &p.Created
+		}
+		if !p.Modified.IsZero() {
+			j.Properties.Modified = &p.Modified
+		}
+		for _, c := range p.Custom {
+			j.Properties.Custom[c.Name] = c.Value
+		}
+	}
+	if !file.ModTime.IsZero() {
+		j.ModTime = &file.ModTime
+	}
+	if trusted := file.Trusted(); trusted != "" {
+		value := trusted == "Yes"
+		j.Trusted = &value
+	}
+	if file.Err != nil {
+		j.Error = file.Err.Error()
+	}
+	return j
+}
+
+// jsonTotals is the JSON representation of the totals of one or more scans.
+type jsonTotals struct {
+	Files      int            `json:"files"`
+	Drawings   int            `json:"drawings"`
+	Failed     int            `json:"failed"`
+	Failures   map[string]int `json:"failures,omitempty"`
+	CacheHits  int            `json:"cacheHits"`
+	FreshReads int            `json:"freshReads"`
+}
+
+// add adds totals to t.
+func (t *jsonTotals) add(totals Totals) {
+	t.Files += totals.Files
+	t.Drawings += totals.Drawings
+	t.Failed += totals.Failed()
+	t.CacheHits += totals.CacheHits
+	t.FreshReads += totals.FreshReads
+	for reason, count := range totals.Failures {
+		if t.Failures == nil {
+			t.Failures = make(map[string]int)
+		}
+		t.Failures[reason.String()] += count
+	}
+}
+
+// jsonRootTotals is the JSON representation of the totals of one root of a
+// scan.
+type jsonRootTotals struct {
+	Root string `json:"root"`
+	jsonTotals
+}
+
+// jsonSlowFile is the JSON representation of a drawing that was slow to
+// read.
+type jsonSlowFile struct {
+	Path     string  `json:"path"`
+	Seconds  float64 `json:"seconds"`
+	TimedOut bool    `json:"timedOut,omitempty"`
+}
+
+// jsonDocument is the JSON representation of the results of one or more
+// scans.
+type jsonDocument struct {
+	Roots      []string         `json:"roots"`
+	Started    time.Time        `json:"started"`
+	Finished   time.Time        `json:"finished"`
+	Workers    int              `json:"workers"`
+	Filter     string           `json:"filter,omitempty"` // The filter that chose which files were written, if any
+	Complete   bool             `json:"complete"`
+	Totals     jsonTotals       `json:"totals"`
+	RootTotals []jsonRootTotals `json:"rootTotals,omitempty"`
+	Slow       []jsonSlowFile   `json:"slow,omitempty"`
+	Errors     []string         `json:"errors,omitempty"`
+	Files      []jsonFile       `json:"files"`
+}
+
+// jsonWriter writes the results of its scans as a single JSON document.
+//
+// The document includes totals that are only known once every scan has
+// finished, so jsonWriter buffers the results until Flush is called.
+type jsonWriter struct {
+	w   io.Writer
+	doc jsonDocument
+}
+
+func newJSONWriter(w io.Writer) *jsonWriter {
+	return &jsonWriter{
+		w:   w,
+		doc: jsonDocument{Roots: []string{}, Files: []jsonFile{}},
+	}
+}
+
+// setFilter records that only the results that satisfy filter are written
+// to the document.
+func (w *jsonWriter) setFilter(filter *Filter) {
+	w.doc.Filter = filter.String()
+}
+
+// Start records the roots of a scan as roots of the document.
+func (w *jsonWriter) Start(roots []string) {
+	w.doc.Roots = append(w.doc.Roots, roots...)
+}
+
+// Append adds the given results to the document.
+func (w *jsonWriter) Append(results ...File) {
+	for _, file := range results {
+		w.doc.Files = append(w.doc.Files, newJSONFile(file))
+	}
+}
+
+// Error records err in the document.
+func (w *jsonWriter) Error(err error) {
+	w.doc.Errors = append(w.doc.Errors, err.Error())
+}
+
+// Finish adds the summary of a scan to the document's metadata and totals.
+func (w *jsonWriter) Finish(summary Summary) {
+	if w.doc.Started.IsZero() {
+		w.doc.Started = summary.Started
+	}
+	w.doc.Finished = summary.Finished
+	w.doc.Workers = summary.Workers
+	w.doc.Totals.add(summary.Totals)
+	for i, root := range summary.Roots {
+		t := jsonRootTotals{Root: root}
+		t.add(summary.PerRoot[i])
+		w.doc.RootTotals = append(w.doc.RootTotals, t)
+	}
+	for _, file := range summary.Slow {
+		w.doc.Slow = append(w.doc.Slow, jsonSlowFile{
+			Path:     file.Path,
+			Seconds:  file.ReadTime.Seconds(),
+			TimedOut: file.Reason == ReasonTimeout,
+		})
+	}
+	w.doc.Complete = w.doc.Totals.Failed == 0 && len(w.doc.Errors) == 0
+}
+
+// Flush writes the document.
+func (w *jsonWriter) Flush() error {
+	enc := json.NewEncoder(w.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(w.doc)
+}
+
+// ndjsonWriter writes each result as a line of JSON as soon as it is
+// collected, so that large scans can be piped into other tools without being
+// held in memory.
+type ndjsonWriter struct {
+	enc *json.Encoder
+	err error
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	return &ndjsonWriter{enc: json.NewEncoder(w)}
+}
+
+// Unbatched returns true. Each result is written as soon as it is collected.
+func (w *ndjsonWriter) Unbatched() bool {
+	return true
+}
+
+// Start has no effect. Results from each scan are written in turn.
+func (w *ndjsonWriter) Start(roots []string) {}
+
+// Append writes the given results.
+func (w *ndjsonWriter) Append(results ...File) {
+	for _, file := range results {
+		if w.err != nil {
+			return
+		}
+		w.err = w.enc.Encode(newJSONFile(file))
+	}
+}
+
+// Error has no effect. Scan errors are reported by the caller.
+func (w *ndjsonWriter) Error(err error) {}
+
+// Finish has no effect. Each result has already been written.
+func (w *ndjsonWriter) Finish(summary Summary) {}
+
+// Flush returns the first error encountered while writing, if any.
+func (w *ndjsonWriter) Flush() error {
+	return w.err
+}
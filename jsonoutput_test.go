@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// scanTree creates a tree with two drawings and a folder that can't be
+// listed, and returns a scanner that reads it along with the tree's root.
+func scanTree(t *testing.T) (*Scanner, string) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"site.dwg":          "",
+		"1042/plan.dwg":     "",
+		"locked/secret.dwg": "",
+	})
+	return stubScanner(2), root
+}
+
+func TestJSONWriter(t *testing.T) {
+	scanner, root := scanTree(t)
+
+	var buf bytes.Buffer
+	w := newJSONWriter(&buf)
+	if _, err := scanAndWait(scanner, []string{root}, w, ScanOptions{}, nil); err != nil {
+		t.Fatalf("scan error = %v", err)
+	}
+	w.Error(errors.New("export interrupted"))
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	var doc jsonDocument
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("the output isn't a JSON document: %v", err)
+	}
+
+	if !reflect.DeepEqual(doc.Roots, []string{root}) {
+		t.Errorf("roots = %q, want %q", doc.Roots, []string{root})
+	}
+	if doc.Started.IsZero() || doc.Finished.Before(doc.Started) {
+		t.Errorf("started %v and finished %v, want a scan that finished after it started", doc.Started, doc.Finished)
+	}
+	if doc.Workers != 2 {
+		t.Errorf("workers = %d, want 2", doc.Workers)
+	}
+
+	totals := jsonTotals{
+		Files:      3,
+		Drawings:   2,
+		Failed:     1,
+		Failures:   map[string]int{ReasonPermission.String(): 1},
+		FreshReads: 2,
+	}
+	if !reflect.DeepEqual(doc.Totals, totals) {
+		t.Errorf("totals = %+v, want %+v", doc.Totals, totals)
+	}
+	if want := []jsonRootTotals{{Root: root, jsonTotals: totals}}; !reflect.DeepEqual(doc.RootTotals, want) {
+		t.Errorf("root totals = %+v, want %+v", doc.RootTotals, want)
+	}
+	if doc.Complete {
+		t.Errorf("complete = true for a scan with failures")
+	}
+	if want := []string{"export interrupted"}; !reflect.DeepEqual(doc.Errors, want) {
+		t.Errorf("errors = %q, want %q", doc.Errors, want)
+	}
+
+	var got []string
+	for _, file := range doc.Files {
+		got = append(got, file.Path)
+	}
+	want := []string{
+		filepath.Join(root, "1042", "plan.dwg"),
+		filepath.Join(root, "locked"),
+		filepath.Join(root, "site.dwg"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %q, want %q", got, want)
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := newNDJSONWriter(&buf)
+	if !unbatched(w) {
+		t.Fatalf("NDJSON results are batched, want them written as they complete")
+	}
+
+	// Each result is written as soon as it is appended
+	w.Append(File{Path: "site.dwg", Format: FormatDWG, Version: "AC1032"})
+	if lines := bytes.Count(buf.Bytes(), []byte("\n")); lines != 1 {
+		t.Fatalf("wrote %d lines after the first result, want 1", lines)
+	}
+	buf.Reset()
+
+	scanner, root := scanTree(t)
+	if _, err := scanAndWait(scanner, []string{root}, w, ScanOptions{Unordered: true}, nil); err != nil {
+		t.Fatalf("scan error = %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	var got []string
+	lines := bufio.NewScanner(&buf)
+	for lines.Scan() {
+		var file jsonFile
+		if err := json.Unmarshal(lines.Bytes(), &file); err != nil {
+			t.Fatalf("line %q isn't a JSON object: %v", lines.Text(), err)
+		}
+		if file.Root != root {
+			t.Errorf("%s has root %q, want %q", file.Path, file.Root, root)
+		}
+		got = append(got, file.Path)
+	}
+	sort.Strings(got)
+
+	want := []string{
+		filepath.Join(root, "1042", "plan.dwg"),
+		filepath.Join(root, "locked"),
+		filepath.Join(root, "site.dwg"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %q, want %q", got, want)
+	}
+}
@@ -1,27 +1,13 @@
 package main
 
 import (
-	"fmt"
 	"os"
 )
 
 func main() {
-	scanModel := &ScanModel{}
-
-	treeModel, err := NewDirectoryTreeModel()
-	if err != nil {
-		fmt.Printf("Failed to prepare directory tree: %v\n", err)
-		os.Exit(1)
-	}
-
-	scanner := NewScanner(scanModel, 32)
-	defer scanner.Stop()
-
-	window, err := NewScanWindow(scanner, treeModel, scanModel)
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
 	}
 
-	window.Run()
+	os.Exit(runGUI())
 }
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// A resultWriter is a Sink that writes results to an output stream.
+type resultWriter interface {
+	Sink
+
+	// Flush writes any buffered output.
+	Flush() error
+}
+
+// newResultWriter returns a resultWriter that writes results to w in the
+// given format.
+func newResultWriter(format string, w io.Writer) (resultWriter, error) {
+	switch strings.ToLower(format) {
+	case "text":
+		return newTextWriter(w), nil
+	case "tsv":
+		return newTSVWriter(w), nil
+	case "csv":
+		return newCSVWriter(w), nil
+	case "json":
+		return newJSONWriter(w), nil
+	case "ndjson":
+		return newNDJSONWriter(w), nil
+	case "html":
+		return newHTMLWriter(w), nil
+	case "xlsx":
+		return newXLSXWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unknown output format \"%s\"", format)
+	}
+}
+
+// exportResults writes files, which were found by the scan described by
+// summary, to a new file at path in the given format. The files may be a
+// subset of the scan's results, so the totals written with them are counted
+// from the files rather than taken from summary.
+func exportResults(path, format string, summary Summary, files []File) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w, err := newResultWriter(format, f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	exported := exportSummary(summary, files)
+	w.Start(exported.Roots)
+	w.Append(files...)
+	w.Finish(exported)
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// exportSummary returns a summary of files with the roots, times and worker
+// count of summary. If summary has no roots, such as when no scan has
+// finished, the roots of the files are used instead.
+func exportSummary(summary Summary, files []File) Summary {
+	roots := summary.Roots
+	if len(roots) == 0 {
+		seen := make(map[string]bool)
+		for _, file := range files {
+			if !seen[file.Root] {
+				seen[file.Root] = true
+				roots = append(roots, file.Root)
+			}
+		}
+	}
+
+	exported := newSummary(roots, summary.Workers)
+	exported.Started, exported.Finished = summary.Started, summary.Finished
+	if exported.Finished.IsZero() {
+		now := time.Now()
+		exported.Started, exported.Finished = now, now
+	}
+
+	included := make(map[string]bool, len(files))
+	for _, file := range files {
+		exported.record(file)
+		included[file.Path] = true
+	}
+	for _, file := range summary.Slow {
+		if included[file.Path] {
+			exported.Slow = append(exported.Slow, file)
+		}
+	}
+	return exported
+}
+
+// textWriter writes results as aligned columns of text.
+//
+// Columns can only be aligned once all of the results are known, so
+// textWriter buffers its output until Flush is called.
+type textWriter struct {
+	tw *tabwriter.Writer
+}
+
+func newTextWriter(w io.Writer) *textWriter {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(columnTitles(resultColumns), "\t"))
+	return &textWriter{tw: tw}
+}
+
+// Start has no effect. Results from each scan are written in turn.
+func (w *textWriter) Start(roots []string) {}
+
+// Append writes the given results.
+func (w *textWriter) Append(results ...File) {
+	for _, file := range results {
+		fmt.Fprintln(w.tw, strings.Join(singleLine(columnValues(resultColumns, file)), "\t"))
+	}
+}
+
+// Error has no effect. Scan errors are reported by the caller.
+func (w *textWriter) Error(err error) {}
+
+// Finish has no effect. Output is completed by Flush.
+func (w *textWriter) Finish(summary Summary) {}
+
+// Flush aligns and writes the buffered results.
+func (w *textWriter) Flush() error {
+	return w.tw.Flush()
+}
+
+// tsvWriter writes results as tab-separated values as soon as they arrive.
+type tsvWriter struct {
+	w   io.Writer
+	err error
+}
+
+func newTSVWriter(w io.Writer) *tsvWriter {
+	tw := &tsvWriter{w: w}
+	tw.write(columnTitles(resultColumns)...)
+	return tw
+}
+
+// Start has no effect. Results from each scan are written in turn.
+func (w *tsvWriter) Start(roots []string) {}
+
+// Append writes the given results.
+func (w *tsvWriter) Append(results ...File) {
+	for _, file := range results {
+		w.write(singleLine(columnValues(resultColumns, file))...)
+	}
+}
+
+// Error has no effect. Scan errors are reported by the caller.
+func (w *tsvWriter) Error(err error) {}
+
+// Finish has no effect. Output is completed by Flush.
+func (w *tsvWriter) Finish(summary Summary) {}
+
+// Flush returns the first error encountered while writing, if any.
+func (w *tsvWriter) Flush() error {
+	return w.err
+}
+
+func (w *tsvWriter) write(fields ...string) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = io.WriteString(w.w, strings.Join(fields, "\t")+"\n")
+}
+
+// csvWriter writes results as comma-separated values as soon as they arrive.
+//
+// The output is meant to open cleanly in Excel: it starts with a UTF-8 byte
+// order mark, uses CRLF line endings and includes the release ordinal so
+// that drawings can be sorted by version.
+type csvWriter struct {
+	w   *csv.Writer
+	err error
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	cw.w.UseCRLF = true
+	_, cw.err = io.WriteString(w, "\ufeff")
+	cw.write(columnTitles(exportColumns))
+	return cw
+}
+
+// Start has no effect. Results from each scan are written in turn.
+func (w *csvWriter) Start(roots []string) {}
+
+// Append writes the given results.
+func (w *csvWriter) Append(results ...File) {
+	for _, file := range results {
+		w.write(columnValues(exportColumns, file))
+	}
+}
+
+// Error has no effect. Scan errors are reported by the caller.
+func (w *csvWriter) Error(err error) {}
+
+// Finish has no effect. Output is completed by Flush.
+func (w *csvWriter) Finish(summary Summary) {}
+
+// Flush writes any buffered results and returns the first error encountered
+// while writing, if any.
+func (w *csvWriter) Flush() error {
+	w.w.Flush()
+	if w.err == nil {
+		w.err = w.w.Error()
+	}
+	return w.err
+}
+
+func (w *csvWriter) write(fields []string) {
+	if w.err != nil {
+		return
+	}
+	w.err = w.w.Write(fields)
+}
+
+// lineBreaks replaces the tabs and line breaks that may appear in drawing
+// properties, so that each value stays within its column.
+var lineBreaks = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ", "\t", " ")
+
+// singleLine replaces tabs and line breaks within values with spaces.
+func singleLine(values []string) []string {
+	for i, v := range values {
+		values[i] = lineBreaks.Replace(v)
+	}
+	return values
+}
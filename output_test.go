@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSVWriter(t *testing.T) {
+	files := []File{
+		{
+			Path:       `C:\Projects\1042\Site, Phase 1.dwg`,
+			Root:       `C:\Projects`,
+			Format:     FormatDWG,
+			Version:    "AC1032",
+			Properties: &DrawingProperties{Title: `The "final" plan`, Comments: "Line one\r\nLine two"},
+		},
+		{
+			Path:   `C:\Projects\notes.dwg`,
+			Root:   `C:\Projects`,
+			Format: FormatDWG,
+			Reason: ReasonNotDrawing,
+			Err:    errors.New("not a drawing"),
+		},
+	}
+
+	var buf bytes.Buffer
+	w := newCSVWriter(&buf)
+	w.Append(files...)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "\ufeff") {
+		t.Errorf("output doesn't start with a byte order mark")
+	}
+	if !strings.HasSuffix(out, "\r\n") {
+		t.Errorf("output doesn't use CRLF line endings")
+	}
+
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(out, "\ufeff"))).ReadAll()
+	if err != nil {
+		t.Fatalf("output can't be parsed: %v", err)
+	}
+	if len(records) != len(files)+1 {
+		t.Fatalf("got %d records, want a header and %d results", len(records), len(files))
+	}
+
+	column := make(map[string]int)
+	for i, title := range records[0] {
+		column[title] = i
+	}
+
+	tests := []struct {
+		row   int
+		title string
+		want  string
+	}{
+		{1, "File", files[0].Path},
+		{1, "Root", `C:\Projects`},
+		{1, "Version", "AutoCAD 2018+"},
+		{1, "Release", "18"},
+		{1, "Title", `The "final" plan`},
+		{1, "Comments", "Line one\nLine two"}, // The reader normalizes line breaks within quotes
+		{2, "Release", ""},
+		{2, "Error", "not a drawing"},
+	}
+
+	for _, tt := range tests {
+		i, ok := column[tt.title]
+		if !ok {
+			t.Errorf("output has no %s column", tt.title)
+			continue
+		}
+		if got := records[tt.row][i]; got != tt.want {
+			t.Errorf("row %d %s = %q, want %q", tt.row, tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestTSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTSVWriter(&buf)
+	w.Append(File{
+		Path:       "plan.dwg",
+		Format:     FormatDWG,
+		Version:    "AC1027",
+		Properties: &DrawingProperties{Title: "Site\tplan", Comments: "Line one\nLine two"},
+	})
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want a header and one result:\n%s", len(lines), buf.String())
+	}
+	header, row := strings.Split(lines[0], "\t"), strings.Split(lines[1], "\t")
+	if len(row) != len(header) {
+		t.Fatalf("result has %d fields, want %d", len(row), len(header))
+	}
+	for i, title := range header {
+		switch title {
+		case "Title":
+			if row[i] != "Site plan" {
+				t.Errorf("Title = %q, want %q", row[i], "Site plan")
+			}
+		case "Comments":
+			if row[i] != "Line one Line two" {
+				t.Errorf("Comments = %q, want %q", row[i], "Line one Line two")
+			}
+		}
+	}
+}
+
+func TestExportResults(t *testing.T) {
+	root := filepath.Join("srv", "projects")
+	files := []File{
+		{Path: filepath.Join(root, "a.dwg"), Root: root, Format: FormatDWG, Version: "AC1032"},
+		{Path: filepath.Join(root, "b.dwg"), Root: root, Format: FormatDWG, Version: "AC1015"},
+		{Path: filepath.Join(root, "c.dwg"), Root: root, Reason: ReasonTruncated, Err: errors.New("truncated")},
+	}
+	started := time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)
+	summary := Summary{
+		Roots:    []string{root},
+		Workers:  32,
+		Started:  started,
+		Finished: started.Add(time.Minute),
+		Totals:   Totals{Files: 10, Drawings: 9},
+		PerRoot:  []Totals{{Files: 10, Drawings: 9}},
+		Slow:     []File{files[1], {Path: filepath.Join(root, "filtered.dwg")}},
+	}
+
+	tests := []struct {
+		name     string
+		summary  Summary
+		contains []string
+	}{
+		{"Scan", summary, []string{"3 found, 2 drawings read, 1 could not be read", root, "with 32 workers", "b.dwg"}},
+		{"NoScan", Summary{}, []string{"3 found, 2 drawings read, 1 could not be read", root}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "report.html")
+			if err := exportResults(path, "html", tt.summary, files); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := html.UnescapeString(string(data))
+			for _, want := range tt.contains {
+				if !strings.Contains(report, want) {
+					t.Errorf("the report doesn't contain %q", want)
+				}
+			}
+			if strings.Contains(report, "filtered.dwg") {
+				t.Errorf("the report lists a slow file that wasn't exported")
+			}
+		})
+	}
+}
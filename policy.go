@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Policy is a drawing version compliance policy. It maps path patterns to
+// the range of drawing versions that files matching them may be saved in.
+//
+// Policies are stored as JSON:
+//
+//	{
+//		"rules": [
+//			{"pattern": "Deliverables/**", "min": "AC1015", "max": "AC1027"},
+//			{"pattern": "**", "min": "AC1015"}
+//		]
+//	}
+//
+// The first rule whose pattern matches a file applies to it. Files that no
+// rule matches are not checked.
+type Policy struct {
+	Rules []PolicyRule `json:"rules"`
+}
+
+// PolicyRule limits the versions of drawings whose paths match Pattern.
+//
+// Pattern is matched against the path of each file relative to the scanned
+// root, using forward slashes and ignoring case. A pattern without a slash
+// matches the file's name anywhere in the tree. Within a pattern, "**"
+// matches any number of directories and the other wildcards are those of
+// path.Match.
+//
+// Min and Max are drawing version codes, either of which may be empty to
+// leave that end of the range open. Versions are compared by release.
+type PolicyRule struct {
+	Pattern string         `json:"pattern"`
+	Min     DrawingVersion `json:"min,omitempty"`
+	Max     DrawingVersion `json:"max,omitempty"`
+}
+
+// Violation describes a file that does not comply with a policy.
+type Violation struct {
+	File    File
+	Rule    PolicyRule
+	Message string
+}
+
+// LoadPolicy reads the policy stored in the file with the given name.
+func LoadPolicy(name string) (*Policy, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %v", name, err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %v", name, err)
+	}
+
+	return &p, nil
+}
+
+// validate returns an error if any of the policy's rules are malformed.
+func (p *Policy) validate() error {
+	for i, rule := range p.Rules {
+		if rule.Pattern == "" {
+			return fmt.Errorf("rule %d has no pattern", i+1)
+		}
+		if _, err := path.Match(rule.Pattern, ""); err != nil {
+			return fmt.Errorf("rule %d has a malformed pattern \"%s\"", i+1, rule.Pattern)
+		}
+		for _, v := range []DrawingVersion{rule.Min, rule.Max} {
+			if v != "" && v.Release() == 0 {
+				return fmt.Errorf("rule %d has an unknown version \"%s\"", i+1, v)
+			}
+		}
+		if rule.Min != "" && rule.Max != "" && rule.Min.Release() > rule.Max.Release() {
+			return fmt.Errorf("rule %d has a minimum version newer than its maximum", i+1)
+		}
+	}
+	return nil
+}
+
+// Rule returns the rule that applies to the file at path within root, or
+// false if no rule applies.
+func (p *Policy) Rule(root, path string) (PolicyRule, bool) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	rel = strings.ToLower(filepath.ToSlash(rel))
+
+	for _, rule := range p.Rules {
+		if matchPattern(strings.ToLower(rule.Pattern), rel) {
+			return rule, true
+		}
+	}
+	return PolicyRule{}, false
+}
+
+// check evaluates file against the rule. It returns nil if the file
+// complies.
+//
+// Files that the rule applies to but whose version couldn't be determined
+// are violations, because they can't be shown to comply.
+func (rule PolicyRule) check(file File) *Violation {
+	if rule.Min == "" && rule.Max == "" {
+		return nil
+	}
+
+	violation := func(format string, args ...interface{}) *Violation {
+		return &Violation{File: file, Rule: rule, Message: fmt.Sprintf(format, args...)}
+	}
+
+	release := file.Version.Release()
+	switch {
+	case file.Failed():
+		return violation("version could not be determined: %s", file.Reason)
+	case rule.Min != "" && release < rule.Min.Release():
+		return violation("%s is older than the minimum allowed version %s (%s)", file.Version, rule.Min, rule.Min.ReleaseName())
+	case rule.Max != "" && release > rule.Max.Release():
+		return violation("%s is newer than the maximum allowed version %s (%s)", file.Version, rule.Max, rule.Max.ReleaseName())
+	default:
+		return nil
+	}
+}
+
+// matchPattern reports whether name matches pattern. Both use forward
+// slashes. A pattern without a slash is matched against the last element of
+// name, and "**" elements match any number of elements.
+func matchPattern(pattern, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchElements(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchElements(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchElements(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// policyChecker is a Sink that evaluates each scanned file against a policy
+// and records the violations.
+//
+// Directories that couldn't be listed aren't checked, as the policy's rules
+// apply to files. They are recorded in unlisted instead, as the drawings
+// within them went unchecked.
+type policyChecker struct {
+	policy     *Policy
+	checked    int
+	violations []Violation
+	unlisted   []File
+}
+
+// Start has no effect. Rule patterns are relative to the root of each file.
+func (c *policyChecker) Start(roots []string) {}
+
+// Append checks the given results against the policy.
+func (c *policyChecker) Append(files ...File) {
+	for _, file := range files {
+		if file.Dir {
+			c.unlisted = append(c.unlisted, file)
+			continue
+		}
+		rule, ok := c.policy.Rule(file.Root, file.Path)
+		if !ok {
+			continue
+		}
+		c.checked++
+		if v := rule.check(file); v != nil {
+			c.violations = append(c.violations, *v)
+		}
+	}
+}
+
+// Error has no effect. Scan errors are reported by the caller.
+func (c *policyChecker) Error(err error) {}
+
+// Finish has no effect. Violations are reported by the caller.
+func (c *policyChecker) Finish(summary Summary) {}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"*.dwg", "site.dwg", true},
+		{"*.dwg", "1042/drawings/site.dwg", true},
+		{"*.dwg", "site.dxf", false},
+		{"deliverables/**", "deliverables/site.dwg", true},
+		{"deliverables/**", "deliverables/phase 1/site.dwg", true},
+		{"deliverables/**", "archive/deliverables/site.dwg", false},
+		{"**/deliverables/**", "archive/deliverables/site.dwg", true},
+		{"**/*.dxf", "site.dxf", true},
+		{"*/drawings/*.dwg", "1042/drawings/site.dwg", true},
+		{"*/drawings/*.dwg", "1042/drawings/old/site.dwg", false},
+		{"**", "anything/at/all.dwg", true},
+	}
+
+	for _, tt := range tests {
+		if got := matchPattern(tt.pattern, tt.name); got != tt.match {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.match)
+		}
+	}
+}
+
+func TestPolicyRule(t *testing.T) {
+	policy := &Policy{Rules: []PolicyRule{
+		{Pattern: "Deliverables/**", Min: "AC1015", Max: "AC1027"},
+		{Pattern: "*.dxf"},
+		{Pattern: "Projects/**", Min: "AC1018"},
+	}}
+	root := filepath.Join("srv", "share")
+
+	tests := []struct {
+		path    string
+		pattern string
+		ok      bool
+	}{
+		{filepath.Join(root, "deliverables", "site.dwg"), "Deliverables/**", true},
+		{filepath.Join(root, "Deliverables", "site.dxf"), "Deliverables/**", true},
+		{filepath.Join(root, "Projects", "site.dxf"), "*.dxf", true},
+		{filepath.Join(root, "Projects", "site.dwg"), "Projects/**", true},
+		{filepath.Join(root, "Archive", "site.dwg"), "", false},
+	}
+
+	for _, tt := range tests {
+		rule, ok := policy.Rule(root, tt.path)
+		if ok != tt.ok || rule.Pattern != tt.pattern {
+			t.Errorf("Rule(%q) = %q, %v, want %q, %v", tt.path, rule.Pattern, ok, tt.pattern, tt.ok)
+		}
+	}
+}
+
+func TestPolicyRuleCheck(t *testing.T) {
+	rule := PolicyRule{Pattern: "**", Min: "AC1015", Max: "AC1027"}
+
+	tests := []struct {
+		name    string
+		rule    PolicyRule
+		file    File
+		message string
+	}{
+		{"Minimum", rule, File{Version: "AC1015"}, ""},
+		{"Maximum", rule, File{Version: "AC1027"}, ""},
+		{"TooOld", rule, File{Version: "AC1014"}, "older than the minimum"},
+		{"TooNew", rule, File{Version: "AC1032"}, "newer than the maximum"},
+		{"Unreadable", rule, File{Reason: ReasonTruncated, Err: errors.New("truncated")}, "could not be determined"},
+		{"Unlimited", PolicyRule{Pattern: "**"}, File{Reason: ReasonTruncated}, ""},
+		{"OpenMinimum", PolicyRule{Pattern: "**", Max: "AC1018"}, File{Version: "AC1009"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := tt.rule.check(tt.file)
+			switch {
+			case tt.message == "" && v != nil:
+				t.Errorf("check() = %q, want no violation", v.Message)
+			case tt.message != "" && v == nil:
+				t.Errorf("check() = nil, want a violation")
+			case v != nil && !strings.Contains(v.Message, tt.message):
+				t.Errorf("check() = %q, want a message containing %q", v.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		err    string
+	}{
+		{"Valid", `{"rules": [{"pattern": "**", "min": "AC1015", "max": "AC1032"}]}`, ""},
+		{"NoPattern", `{"rules": [{"min": "AC1015"}]}`, "rule 1 has no pattern"},
+		{"BadPattern", `{"rules": [{"pattern": "**"}, {"pattern": "[a-"}]}`, "rule 2 has a malformed pattern"},
+		{"UnknownVersion", `{"rules": [{"pattern": "**", "max": "AC1099"}]}`, "unknown version"},
+		{"Reversed", `{"rules": [{"pattern": "**", "min": "AC1032", "max": "AC1015"}]}`, "newer than its maximum"},
+		{"NotJSON", `rules: all`, "invalid policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "policy.json")
+			if err := os.WriteFile(name, []byte(tt.policy), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := LoadPolicy(name)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("LoadPolicy() error = %v", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("LoadPolicy() error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestPolicyChecker(t *testing.T) {
+	root := filepath.Join("srv", "share")
+	checker := &policyChecker{policy: &Policy{Rules: []PolicyRule{
+		{Pattern: "Deliverables/**", Max: "AC1027"},
+	}}}
+	locked := File{Path: filepath.Join(root, "Deliverables", "Locked"), Root: root, Dir: true, Reason: ReasonPermission}
+
+	checker.Append(
+		File{Path: filepath.Join(root, "Deliverables", "a.dwg"), Root: root, Version: "AC1027"},
+		File{Path: filepath.Join(root, "Deliverables", "b.dwg"), Root: root, Version: "AC1032"},
+		File{Path: filepath.Join(root, "Working", "c.dwg"), Root: root, Version: "AC1032"},
+		locked,
+	)
+
+	if checker.checked != 2 {
+		t.Errorf("checked %d files, want 2", checker.checked)
+	}
+	if len(checker.violations) != 1 || filepath.Base(checker.violations[0].File.Path) != "b.dwg" {
+		t.Errorf("violations = %+v, want only b.dwg", checker.violations)
+	}
+	if len(checker.unlisted) != 1 || checker.unlisted[0].Path != locked.Path {
+		t.Errorf("unlisted = %+v, want only the locked folder", checker.unlisted)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/scjalliance/cadscan/dwg"
+)
+
+// runPreviews scans one or more directories for drawings and saves their
+// preview images to a folder.
+func runPreviews(args []string) int {
+	flags := flag.NewFlagSet("previews", flag.ContinueOnError)
+	var scanArgs scanArgs
+	scanArgs.register(flags, false) // Cached drawings aren't read, so their previews wouldn't be saved
+	output := flags.String("o", "previews", "folder to save preview images in")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: cadscan previews [flags] <root>...\n\n")
+		fmt.Fprintf(flags.Output(), "Each preview is saved under the output folder with the path of its drawing,\n")
+		fmt.Fprintf(flags.Output(), "plus an extension for the image format.\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	roots := flags.Args()
+	if len(roots) == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	scanner, options, status := scanArgs.open(roots)
+	if status != 0 {
+		return status
+	}
+	options.Unordered = true
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	// Previews are read by the scan's workers, so that they are read
+	// concurrently and are subject to the read timeout, and saved as their
+	// drawings' results are collected
+	writer := &previewWriter{dir: *output}
+	scanner.read = writer.read
+	summary, err := scanAndWait(scanner, roots, writer, options, interrupt)
+	if err == nil {
+		writeSummary(os.Stderr, summary)
+	}
+
+	fmt.Fprintf(os.Stderr, "Saved %d previews to %s.", writer.saved, *output)
+	if writer.missing > 0 {
+		fmt.Fprintf(os.Stderr, " %d drawings had no preview.", writer.missing)
+	}
+	fmt.Fprintf(os.Stderr, "\n")
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Scan failed: %v\n", err)
+		return 1
+	}
+	if writer.failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// previewWriter is a Sink that saves the preview images read by its read
+// function, which reads each drawing of a scan along with its preview, to a
+// folder.
+//
+// Previews are only saved once their drawings' results are appended, so that
+// the preview of a drawing whose read timed out is never saved, even if the
+// abandoned read finishes later.
+type previewWriter struct {
+	dir   string   // The folder to save previews in
+	roots []string // The roots of the scan
+
+	mutex     sync.Mutex
+	outcomes  map[string]previewOutcome // Previews read that have yet to be appended, by path
+	abandoned map[string]bool           // Paths whose reads timed out and are still running
+
+	saved   int
+	missing int
+	failed  int
+}
+
+// previewOutcome is the result of reading the preview of a drawing.
+type previewOutcome struct {
+	preview *DrawingPreview
+	err     error
+}
+
+func (w *previewWriter) Start(roots []string) {
+	w.roots = roots
+}
+
+func (w *previewWriter) Append(files ...File) {
+	for _, file := range files {
+		var err error
+		switch {
+		case file.Reason == ReasonTimeout:
+			w.discard(file.Path)
+			err = file.Err
+		case file.Failed() || file.Format != FormatDWG:
+			continue
+		default:
+			w.mutex.Lock()
+			outcome, ok := w.outcomes[file.Path]
+			delete(w.outcomes, file.Path)
+			w.mutex.Unlock()
+			if !ok {
+				continue
+			}
+
+			err = outcome.err
+			if err == nil {
+				err = w.save(file.Path, outcome.preview)
+			}
+		}
+
+		switch {
+		case err == nil:
+			w.saved++
+		case errors.Is(err, dwg.ErrNoPreview):
+			w.missing++
+		default:
+			w.failed++
+			fmt.Fprintf(os.Stderr, "Unable to save preview of %s: %v\n", file.Path, err)
+		}
+	}
+}
+
+func (w *previewWriter) Error(err error) {}
+
+func (w *previewWriter) Finish(summary Summary) {}
+
+// read reads the drawing at path and its preview image, if it has one. It is
+// called by the scan's workers.
+func (w *previewWriter) read(path string) File {
+	file := readFile(path)
+	if file.Failed() || file.Format != FormatDWG {
+		return file
+	}
+
+	preview, err := ReadDrawingPreview(path)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.abandoned[path] {
+		// The read timed out and its result has already been appended
+		delete(w.abandoned, path)
+		return file
+	}
+	if w.outcomes == nil {
+		w.outcomes = make(map[string]previewOutcome)
+	}
+	w.outcomes[path] = previewOutcome{preview: preview, err: err}
+	return file
+}
+
+// discard drops the preview of the drawing at path, whose read timed out. If
+// the read is still running, its preview is dropped when it finishes.
+func (w *previewWriter) discard(path string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if _, ok := w.outcomes[path]; ok {
+		delete(w.outcomes, path)
+		return
+	}
+	if w.abandoned == nil {
+		w.abandoned = make(map[string]bool)
+	}
+	w.abandoned[path] = true
+}
+
+// save writes preview, the preview image of the drawing at path, to w.dir,
+// under the path of the drawing's root and its path within that root.
+func (w *previewWriter) save(path string, preview *DrawingPreview) error {
+	data, err := preview.File()
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Join(w.dir, w.previewPath(path)+preview.Format.Ext())
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(name, data, 0o644)
+}
+
+// previewPath returns the path of the preview of the drawing at path within
+// the output folder, without an extension. It is made up of the absolute
+// path of the drawing's root, without its volume separator, and the
+// drawing's path within the root, so that drawings with the same relative
+// path in different roots don't overwrite each other's previews.
+func (w *previewWriter) previewPath(path string) string {
+	for _, root := range w.roots {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+		volume := filepath.VolumeName(root)
+		root = strings.TrimSuffix(volume, ":") + root[len(volume):]
+		return filepath.Join(strings.TrimLeft(root, `\/`), rel)
+	}
+	return filepath.Base(path)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreviewPath(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a", "projects"), filepath.Join(dir, "b", "projects")
+	w := &previewWriter{roots: []string{a, b}}
+
+	rel := filepath.Join("1042", "site.dwg")
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"FirstRoot", filepath.Join(a, rel), filepath.Join("a", "projects", rel)},
+		{"SecondRoot", filepath.Join(b, rel), filepath.Join("b", "projects", rel)},
+		{"OutsideRoots", filepath.Join(dir, "c", "plan.dwg"), "plan.dwg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := w.previewPath(tt.path)
+			if filepath.IsAbs(got) || strings.HasPrefix(got, "..") {
+				t.Fatalf("previewPath(%q) = %q, which is outside the output folder", tt.path, got)
+			}
+			if !strings.HasSuffix(got, tt.want) {
+				t.Errorf("previewPath(%q) = %q, want it to end with %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreviewWriterTimeout(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"old.dwg": "AC1009"})
+	path := filepath.Join(dir, "old.dwg")
+	read := File{Path: path, Format: FormatDWG, Version: "AC1009"}
+	timedOut := File{Path: path, Reason: ReasonTimeout, Err: errors.New("no response")}
+
+	tests := []struct {
+		name    string
+		run     func(w *previewWriter)
+		missing int
+		failed  int
+	}{
+		{
+			name: "Read",
+			run: func(w *previewWriter) {
+				w.read(path)
+				w.Append(read)
+			},
+			missing: 1,
+		},
+		{
+			name: "FinishedBeforeTimeout",
+			run: func(w *previewWriter) {
+				w.read(path)
+				w.Append(timedOut)
+			},
+			failed: 1,
+		},
+		{
+			name: "FinishedAfterTimeout",
+			run: func(w *previewWriter) {
+				w.Append(timedOut)
+				w.read(path)
+			},
+			failed: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &previewWriter{dir: filepath.Join(dir, "previews")}
+			tt.run(w)
+
+			if w.saved != 0 || w.missing != tt.missing || w.failed != tt.failed {
+				t.Errorf("saved, missing, failed = %d, %d, %d, want 0, %d, %d", w.saved, w.missing, w.failed, tt.missing, tt.failed)
+			}
+			if len(w.outcomes) != 0 || len(w.abandoned) != 0 {
+				t.Errorf("left %d outcomes and %d abandoned reads behind", len(w.outcomes), len(w.abandoned))
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Progress describes how far a scan has come. Scanners publish it to the
+// Progress function in ScanOptions several times a second while scanning.
+type Progress struct {
+	Roots       []string
+	Elapsed     time.Duration // Time since the scan started
+	Directories int           // Number of directories walked
+	Found       int           // Number of drawings and failures found so far
+	Scanned     int           // Number of results delivered to the sink
+	Cached      int           // Number of results taken from the scan cache
+	Errors      int           // Number of files and directories that could not be assessed
+	BytesRead   int64         // Number of bytes read from files that weren't cached
+	Walked      bool          // Whether the whole tree has been walked, so Found is final
+	Done        bool          // Whether this is the last progress report of the scan
+}
+
+// Rate returns the number of results delivered each second.
+func (p Progress) Rate() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Scanned) / p.Elapsed.Seconds()
+}
+
+// Remaining returns an estimate of the time left until the scan finishes.
+// It returns false until the tree has been walked, as the amount of work
+// left isn't known before then.
+func (p Progress) Remaining() (time.Duration, bool) {
+	if !p.Walked {
+		return 0, false
+	}
+	rate := p.Rate()
+	if rate == 0 {
+		return 0, false
+	}
+	left := float64(p.Found - p.Scanned)
+	return time.Duration(left / rate * float64(time.Second)), true
+}
+
+// String returns a one-line description of the scan's progress.
+func (p Progress) String() string {
+	text := fmt.Sprintf("%d of %d scanned in %d folders", p.Scanned, p.Found, p.Directories)
+	if p.Errors > 0 {
+		text += fmt.Sprintf(", %d errors", p.Errors)
+	}
+	text += fmt.Sprintf(", %s read, %.0f/s, %v elapsed", formatBytes(p.BytesRead), p.Rate(), p.Elapsed.Round(time.Second))
+	switch remaining, ok := p.Remaining(); {
+	case p.Done:
+	case ok:
+		text += fmt.Sprintf(", about %v left", remaining.Round(time.Second))
+	default:
+		text += ", still searching"
+	}
+	return text
+}
+
+// progressCounter accumulates the progress of a scan. The counts kept by the
+// walk are updated atomically, as they're read by the goroutine collecting
+// results; the rest belong to that goroutine.
+type progressCounter struct {
+	directories atomic.Int64
+	found       atomic.Int64
+	walked      atomic.Bool
+
+	progress Progress
+	started  time.Time
+}
+
+// record adds a result delivered to the sink to the counter.
+func (c *progressCounter) record(file File) {
+	c.progress.Scanned++
+	c.progress.BytesRead += file.BytesRead
+	switch {
+	case file.Failed():
+		c.progress.Errors++
+	case file.Cached:
+		c.progress.Cached++
+	}
+}
+
+// snapshot returns the scan's progress as of now.
+func (c *progressCounter) snapshot() Progress {
+	p := c.progress
+	p.Elapsed = time.Since(c.started)
+	p.Directories = int(c.directories.Load())
+	p.Found = int(c.found.Load())
+	p.Walked = c.walked.Load()
+	return p
+}
+
+// progressLine returns a Progress function that keeps a single line of
+// progress up to date on w, which should be a terminal.
+func progressLine(w io.Writer) func(Progress) {
+	var width int
+	return func(p Progress) {
+		text := p.String()
+		pad := width - len(text)
+		if pad < 0 {
+			pad = 0
+		}
+		width = len(text)
+		fmt.Fprintf(w, "\r%s%*s", text, pad, "")
+		if p.Done {
+			fmt.Fprintln(w)
+			width = 0
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProgressRemaining(t *testing.T) {
+	tests := []struct {
+		name     string
+		progress Progress
+		want     time.Duration
+		ok       bool
+	}{
+		{"NotStarted", Progress{Found: 10, Walked: true}, 0, false},
+		{"UnknownTotal", Progress{Elapsed: 2 * time.Second, Found: 10, Scanned: 4}, 0, false},
+		{"NothingScanned", Progress{Elapsed: 2 * time.Second, Found: 10, Walked: true}, 0, false},
+		{"Scanning", Progress{Elapsed: 2 * time.Second, Found: 10, Scanned: 4, Walked: true}, 3 * time.Second, true},
+		{"Finished", Progress{Elapsed: 5 * time.Second, Found: 10, Scanned: 10, Walked: true, Done: true}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.progress.Remaining()
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Remaining() = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestProgressString(t *testing.T) {
+	tests := []struct {
+		name     string
+		progress Progress
+		want     string
+	}{
+		{
+			"NotStarted",
+			Progress{},
+			"0 of 0 scanned in 0 folders, 0 B read, 0/s, 0s elapsed, still searching",
+		},
+		{
+			"UnknownTotal",
+			Progress{Elapsed: 2 * time.Second, Directories: 3, Found: 10, Scanned: 4, BytesRead: 2048},
+			"4 of 10 scanned in 3 folders, 2.0 KiB read, 2/s, 2s elapsed, still searching",
+		},
+		{
+			"Scanning",
+			Progress{Elapsed: 2 * time.Second, Directories: 3, Found: 10, Scanned: 4, Errors: 1, Walked: true},
+			"4 of 10 scanned in 3 folders, 1 errors, 0 B read, 2/s, 2s elapsed, about 3s left",
+		},
+		{
+			"Finished",
+			Progress{Elapsed: 5 * time.Second, Directories: 3, Found: 10, Scanned: 10, Walked: true, Done: true},
+			"10 of 10 scanned in 3 folders, 0 B read, 2/s, 5s elapsed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.progress.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressCounter(t *testing.T) {
+	counter := &progressCounter{
+		progress: Progress{Roots: []string{"projects"}},
+		started:  time.Now().Add(-time.Second),
+	}
+	counter.directories.Add(2)
+	counter.found.Add(4)
+
+	for _, file := range []File{
+		{Path: "site.dwg", Size: 100000, Format: FormatDWG, Version: "AC1032", BytesRead: 100},
+		{Path: "plan.dwg", Size: 200, Format: FormatDWG, Version: "AC1015", Cached: true},
+		{Path: "bad.dwg", Size: 400, Format: FormatDWG, Reason: ReasonTruncated, Err: errors.New("truncated"), BytesRead: 6},
+	} {
+		counter.record(file)
+	}
+
+	got := counter.snapshot()
+	if got.Elapsed < time.Second {
+		t.Errorf("snapshot() Elapsed = %v, want at least 1s", got.Elapsed)
+	}
+	got.Elapsed = 0
+
+	want := Progress{
+		Roots:       []string{"projects"},
+		Directories: 2,
+		Found:       4,
+		Scanned:     3,
+		Cached:      1,
+		Errors:      1,
+		BytesRead:   106,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot() = %+v, want %+v", got, want)
+	}
+
+	counter.walked.Store(true)
+	if got := counter.snapshot(); !got.Walked {
+		t.Errorf("snapshot() Walked = false after the walk finished")
+	}
+}
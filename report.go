@@ -0,0 +1,172 @@
+package main
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reportTemplate is the template of HTML reports. The report is a single
+// self-contained page: its styles, chart and scripts are all inline.
+//
+//go:embed report.html
+var reportTemplate string
+
+var reportPage = template.Must(template.New("report").Funcs(template.FuncMap{"bytes": formatBytes, "join": joinRoots}).Parse(reportTemplate))
+
+// joinRoots returns the roots of a scan as a comma-separated list.
+func joinRoots(roots []string) string {
+	return strings.Join(roots, ", ")
+}
+
+// reportProjectDepth is the depth below each scan root of the folders that
+// HTML reports total the drawings of.
+const reportProjectDepth = 1
+
+// reportChartWidth is the width, in pixels, of the longest bar in the
+// version distribution chart of HTML reports.
+const reportChartWidth = 400
+
+// htmlWriter writes the results of its scans as a standalone HTML report.
+//
+// The report's totals and charts are only known once every scan has
+// finished, so htmlWriter buffers the results until Flush is called.
+type htmlWriter struct {
+	w         io.Writer
+	roots     []string
+	summaries []Summary
+	errors    []string
+	files     []File
+	rollup    *Rollup
+}
+
+func newHTMLWriter(w io.Writer) *htmlWriter {
+	return &htmlWriter{w: w, rollup: NewRollup(reportProjectDepth)}
+}
+
+// Start records the roots of a scan as roots of the report.
+func (w *htmlWriter) Start(roots []string) {
+	w.roots = append(w.roots, roots...)
+	w.rollup.Start(roots)
+}
+
+// Append adds the given results to the report.
+func (w *htmlWriter) Append(results ...File) {
+	w.files = append(w.files, results...)
+	w.rollup.Append(results...)
+}
+
+// Error records err in the report.
+func (w *htmlWriter) Error(err error) {
+	w.errors = append(w.errors, err.Error())
+}
+
+// Finish adds the summary of a scan to the report.
+func (w *htmlWriter) Finish(summary Summary) {
+	w.summaries = append(w.summaries, summary)
+}
+
+// Flush renders and writes the report.
+func (w *htmlWriter) Flush() error {
+	return reportPage.Execute(w.w, w.report())
+}
+
+// report is the data rendered by the report template.
+type report struct {
+	Generated time.Time
+	Roots     []string
+	Summaries []Summary
+	Totals    jsonTotals
+	Versions  []reportVersion
+	Chart     reportChart
+	Folders   []*DirectoryRollup
+	Columns   []string
+	Rows      []reportRow
+	Failures  []File
+	Slow      []File
+	Errors    []string
+}
+
+// reportVersion is a bar of the version distribution chart.
+type reportVersion struct {
+	Header   string
+	Name     string
+	Drawings int
+	Percent  float64
+	Y        int // Top of the bar
+	Width    int // Length of the bar
+	CountX   int // Start of the count that follows the bar
+}
+
+// reportChart holds the dimensions of the version distribution chart.
+type reportChart struct {
+	Height    int
+	BarHeight int
+	BarX      int
+	Width     int
+}
+
+// reportRow is a row of the file table. Release orders rows by version.
+type reportRow struct {
+	Release int
+	Values  []string
+}
+
+// report collects the data for the report from the recorded results.
+func (w *htmlWriter) report() report {
+	r := report{
+		Generated: time.Now(),
+		Roots:     w.roots,
+		Summaries: w.summaries,
+		Folders:   w.rollup.Directories(),
+		Columns:   columnTitles(resultColumns),
+		Errors:    w.errors,
+	}
+
+	for _, summary := range w.summaries {
+		r.Totals.add(summary.Totals)
+		r.Slow = append(r.Slow, summary.Slow...)
+	}
+
+	counts := make(map[DrawingVersion]int)
+	for _, file := range w.files {
+		r.Rows = append(r.Rows, reportRow{Release: file.Version.Release(), Values: columnValues(resultColumns, file)})
+		if file.Failed() {
+			r.Failures = append(r.Failures, file)
+			continue
+		}
+		counts[file.Version]++
+	}
+
+	most, drawings := 0, 0
+	for version, count := range counts {
+		drawings += count
+		r.Versions = append(r.Versions, reportVersion{Header: version.String(), Name: version.ReleaseName(), Drawings: count})
+		if count > most {
+			most = count
+		}
+	}
+	sort.Slice(r.Versions, func(i, j int) bool {
+		return DrawingVersion(r.Versions[i].Header).Release() < DrawingVersion(r.Versions[j].Header).Release()
+	})
+
+	const barHeight, barGap, labelWidth = 22, 6, 320
+	for i := range r.Versions {
+		v := &r.Versions[i]
+		v.Y = i * (barHeight + barGap)
+		v.Width = 1 + v.Drawings*(reportChartWidth-1)/most
+		v.CountX = labelWidth + v.Width + 8
+		v.Percent = 100 * float64(v.Drawings) / float64(drawings)
+	}
+	r.Chart = reportChart{
+		Height:    len(r.Versions) * (barHeight + barGap),
+		BarHeight: barHeight,
+		BarX:      labelWidth,
+		Width:     labelWidth + reportChartWidth + 120,
+	}
+
+	return r
+}
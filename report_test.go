@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scjalliance/cadscan/dwg"
+)
+
+func TestHTMLWriter(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+	writeTree(t, a, map[string]string{
+		"site.dwg":          "",
+		"1042/plan.dwg":     "",
+		"1042/old.dwg":      "",
+		"1042/bad.dwg":      "",
+		"locked/secret.dwg": "",
+	})
+	writeTree(t, b, map[string]string{"stuck.dwg": ""})
+
+	// The stuck drawing times out, so it's listed as a slow file
+	release := make(chan struct{})
+	defer close(release)
+	stuck := stuckRead(release)
+	scanner := stubScanner(2)
+	scanner.read = func(path string) File {
+		switch filepath.Base(path) {
+		case "old.dwg":
+			return File{Path: path, Format: FormatDWG, Version: "AC1009"}
+		case "bad.dwg":
+			return File{Path: path, Format: FormatDWG, Reason: ReasonTruncated, Err: dwg.ErrTruncated}
+		}
+		return stuck(path)
+	}
+
+	var buf bytes.Buffer
+	w := newHTMLWriter(&buf)
+	summary, err := scanAndWait(scanner, []string{a, b}, w, ScanOptions{Timeout: 20 * time.Millisecond}, nil)
+	if err != nil {
+		t.Fatalf("scan error = %v", err)
+	}
+	if len(summary.Slow) != 1 {
+		t.Fatalf("Slow = %+v, want only stuck.dwg", summary.Slow)
+	}
+	w.Error(errors.New("export interrupted"))
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	report := html.UnescapeString(buf.String())
+
+	old, latest := DrawingVersion("AC1009"), DrawingVersion("AC1032")
+	for _, want := range []string{
+		// Totals of each root
+		fmt.Sprintf("<tr><th></th><td>%s: %s.</td></tr>", a, summary.PerRoot[0]),
+		fmt.Sprintf("<tr><th></th><td>%s: %s.</td></tr>", b, summary.PerRoot[1]),
+		"6 found, 3 drawings read, 3 could not be read",
+		"Coverage is incomplete.",
+
+		// Chart bars, from the oldest version to the newest
+		fmt.Sprintf(`<text x="0" y="22" dy="-6">AC1009 %s</text>`, old.ReleaseName()),
+		`<rect x="320" y="0" width="200" height="22"><title>1 drawings</title></rect>`,
+		`1 (33.3%)`,
+		fmt.Sprintf(`<text x="0" y="22" dy="-6">AC1032 %s</text>`, latest.ReleaseName()),
+		`<rect x="320" y="0" width="400" height="22"><title>2 drawings</title></rect>`,
+		`2 (66.7%)`,
+
+		// Folder totals, with their versions and unreadable files
+		fmt.Sprintf(`<tr><td><strong>%s</strong></td><td></td><td class="number"><strong>1</strong></td>`, a),
+		fmt.Sprintf(`<tr><td><strong>%s</strong></td><td></td><td class="number"><strong>2</strong></td>`, filepath.Join(a, "1042")),
+		fmt.Sprintf(`<td class="version">AC1009 %s</td><td class="number">1</td>`, old.ReleaseName()),
+		`<td class="version">Unreadable files</td><td class="number">1</td>`,
+
+		// Failures and scan errors
+		fmt.Sprintf("<tr><td>%s</td><td>truncated header</td><td>%v</td></tr>", filepath.Join(a, "1042", "bad.dwg"), dwg.ErrTruncated),
+		fmt.Sprintf("<tr><td>%s</td><td>permission denied</td>", filepath.Join(a, "locked")),
+		"<tr><td></td><td>scan stopped</td><td>export interrupted</td></tr>",
+
+		// Slow files
+		fmt.Sprintf("<tr><td>%s</td><td>%v (timed out)</td></tr>", filepath.Join(b, "stuck.dwg"), summary.Slow[0].ReadTime.Round(time.Millisecond)),
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("the report doesn't contain %q", want)
+		}
+	}
+
+	// The oldest version's bar comes first
+	if i, j := strings.Index(report, "AC1009 "+old.ReleaseName()), strings.Index(report, "AC1032 "+latest.ReleaseName()); i > j {
+		t.Errorf("the chart lists AC1032 before AC1009")
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// VersionTotals counts the drawings of one version and their total size.
+type VersionTotals struct {
+	Drawings int
+	Bytes    int64
+}
+
+// DirectoryRollup holds the totals for each drawing version found within a
+// directory and its subdirectories.
+//
+// Files that could not be assessed are counted in Failed rather than as
+// drawings. Directories that could not be walked are left out; they are
+// reported in the scan's summary instead.
+type DirectoryRollup struct {
+	Path     string
+	Depth    int // Depth of the directory below its scan root, which has depth 0
+	Total    VersionTotals
+	Versions map[DrawingVersion]*VersionTotals
+	Failed   int // Number of files that could not be assessed
+}
+
+// SortedVersions returns the versions in the rollup from the oldest release
+// to the newest.
+func (d *DirectoryRollup) SortedVersions() []DrawingVersion {
+	versions := make([]DrawingVersion, 0, len(d.Versions))
+	for v := range d.Versions {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		a, b := versions[i], versions[j]
+		if a.Release() != b.Release() {
+			return a.Release() < b.Release()
+		}
+		return a < b
+	})
+	return versions
+}
+
+// add counts file in the directory's totals.
+func (d *DirectoryRollup) add(file File) {
+	if file.Failed() {
+		d.Failed++
+		return
+	}
+	totals := d.Versions[file.Version]
+	if totals == nil {
+		totals = &VersionTotals{}
+		d.Versions[file.Version] = totals
+	}
+	totals.Drawings++
+	totals.Bytes += file.Size
+	d.Total.Drawings++
+	d.Total.Bytes += file.Size
+}
+
+// Rollup is a Sink that aggregates scan results by directory.
+//
+// With a project depth of zero, every directory from the scan root down to
+// each file's own directory includes the file in its totals. Otherwise each
+// file is counted once, in the project folder that many levels below the
+// scan root that contains it. Files above that depth are counted in their own
+// directory.
+type Rollup struct {
+	depth int
+	dirs  map[string]*DirectoryRollup
+}
+
+// NewRollup returns a rollup that groups results into project folders at the
+// given depth below each scan root, or into every directory level if depth
+// is zero.
+func NewRollup(depth int) *Rollup {
+	return &Rollup{
+		depth: depth,
+		dirs:  make(map[string]*DirectoryRollup),
+	}
+}
+
+// Start has no effect. Directory depths are measured from the root of each
+// file.
+func (r *Rollup) Start(roots []string) {}
+
+// Append adds the given results to the totals of their directories.
+func (r *Rollup) Append(files ...File) {
+	for _, file := range files {
+		if file.Dir {
+			continue
+		}
+
+		root := filepath.Clean(file.Root)
+		rel, err := filepath.Rel(root, filepath.Dir(file.Path))
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			rel = ""
+		}
+
+		var elements []string
+		if rel != "" {
+			elements = strings.Split(rel, string(filepath.Separator))
+		}
+
+		if r.depth > 0 {
+			if len(elements) > r.depth {
+				elements = elements[:r.depth]
+			}
+			r.directory(root, elements).add(file)
+			continue
+		}
+
+		for depth := 0; depth <= len(elements); depth++ {
+			r.directory(root, elements[:depth]).add(file)
+		}
+	}
+}
+
+// Error has no effect. Scan errors are reported by the caller.
+func (r *Rollup) Error(err error) {}
+
+// Finish has no effect. The rollup is complete once the scan has ended.
+func (r *Rollup) Finish(summary Summary) {}
+
+// directory returns the totals for the directory with the given path
+// elements below root, creating them if necessary.
+func (r *Rollup) directory(root string, elements []string) *DirectoryRollup {
+	path := filepath.Join(append([]string{root}, elements...)...)
+	d := r.dirs[path]
+	if d == nil {
+		d = &DirectoryRollup{
+			Path:     path,
+			Depth:    len(elements),
+			Versions: make(map[DrawingVersion]*VersionTotals),
+		}
+		r.dirs[path] = d
+	}
+	return d
+}
+
+// Directories returns the totals of each directory, ordered by path.
+func (r *Rollup) Directories() []*DirectoryRollup {
+	dirs := make([]*DirectoryRollup, 0, len(r.dirs))
+	for _, d := range r.dirs {
+		dirs = append(dirs, d)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return dirs[i].Path < dirs[j].Path
+	})
+	return dirs
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRollup(t *testing.T) {
+	root := filepath.Join("srv", "projects")
+	p := func(elem ...string) string {
+		return filepath.Join(append([]string{root}, elem...)...)
+	}
+	files := []File{
+		{Path: p("index.dwg"), Root: root, Version: "AC1032", Size: 100},
+		{Path: p("1042", "site.dwg"), Root: root, Version: "AC1015", Size: 10},
+		{Path: p("1042", "old", "plan.dwg"), Root: root, Version: "AC1015", Size: 20},
+		{Path: p("1042", "notes.dwg"), Root: root, Reason: ReasonNotDrawing, Size: 1},
+		{Path: p("1043"), Root: root, Dir: true, Reason: ReasonPermission, Err: errors.New("access denied")},
+	}
+
+	// Totals of each directory by version, with "" for unreadable files,
+	// which aren't counted as drawings
+	type totals map[DrawingVersion]int
+
+	tests := []struct {
+		name     string
+		depth    int
+		want     map[string]totals
+		drawings map[string]int
+	}{
+		{
+			name:  "EveryLevel",
+			depth: 0,
+			want: map[string]totals{
+				root:             {"AC1032": 1, "AC1015": 2, "": 1},
+				p("1042"):        {"AC1015": 2, "": 1},
+				p("1042", "old"): {"AC1015": 1},
+			},
+			drawings: map[string]int{root: 3, p("1042"): 2, p("1042", "old"): 1},
+		},
+		{
+			name:  "Projects",
+			depth: 1,
+			want: map[string]totals{
+				root:      {"AC1032": 1},
+				p("1042"): {"AC1015": 2, "": 1},
+			},
+			drawings: map[string]int{root: 1, p("1042"): 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rollup := NewRollup(tt.depth)
+			rollup.Append(files...)
+
+			got := make(map[string]totals)
+			drawings := make(map[string]int)
+			for _, dir := range rollup.Directories() {
+				got[dir.Path] = make(totals)
+				for version, v := range dir.Versions {
+					got[dir.Path][version] = v.Drawings
+				}
+				if dir.Failed > 0 {
+					got[dir.Path][""] = dir.Failed
+				}
+				drawings[dir.Path] = dir.Total.Drawings
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rollup = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(drawings, tt.drawings) {
+				t.Errorf("drawing totals = %v, want %v", drawings, tt.drawings)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// runRollup scans one or more directories and prints the number and size
+// of the drawings of each version in each directory.
+func runRollup(args []string) int {
+	flags := flag.NewFlagSet("rollup", flag.ContinueOnError)
+	var scanArgs scanArgs
+	scanArgs.register(flags, true)
+	depth := flags.Int("depth", 0, "depth of project folders below each root, or 0 to total every directory level")
+	format := flags.String("format", "report", "output format: report, tsv or csv")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: cadscan rollup [flags] <root>...\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	roots := flags.Args()
+	if len(roots) == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	if *depth < 0 {
+		fmt.Fprintf(os.Stderr, "The project depth can't be negative.\n")
+		return 2
+	}
+
+	var write func(io.Writer, *Rollup) error
+	switch strings.ToLower(*format) {
+	case "report":
+		write = writeRollupReport
+	case "tsv":
+		write = writeRollupTSV
+	case "csv":
+		write = writeRollupCSV
+	default:
+		fmt.Fprintf(os.Stderr, "unknown output format \"%s\"\n", *format)
+		return 2
+	}
+
+	scanner, options, status := scanArgs.open(roots)
+	if status != 0 {
+		return status
+	}
+	options.Unordered = true // Totals don't depend on the order of the results
+
+	rollup := NewRollup(*depth)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	summary, err := scanAndWait(scanner, roots, rollup, options, interrupt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Scan failed: %v\n", err)
+		return 1
+	}
+	writeSummary(os.Stderr, summary)
+
+	saveCache(options)
+
+	if err := write(os.Stdout, rollup); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write the rollup: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// writeRollupReport writes the rollup as an indented report, with each
+// directory's total followed by its totals for each version and the number
+// of files that couldn't be read.
+func writeRollupReport(w io.Writer, rollup *Rollup) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for i, dir := range rollup.Directories() {
+		if i > 0 {
+			fmt.Fprintf(tw, "\t\t\t\n")
+		}
+		fmt.Fprintf(tw, "%s\t\t%d\t%s\n", dir.Path, dir.Total.Drawings, formatBytes(dir.Total.Bytes))
+		for _, version := range dir.SortedVersions() {
+			totals := dir.Versions[version]
+			fmt.Fprintf(tw, "    %s\t%s\t%d\t%s\n", version, version.ReleaseName(), totals.Drawings, formatBytes(totals.Bytes))
+		}
+		if dir.Failed > 0 {
+			fmt.Fprintf(tw, "    (unreadable)\t\t%d\t\n", dir.Failed)
+		}
+	}
+	return tw.Flush()
+}
+
+// rollupRows returns the rollup as a table with a row for each version in
+// each directory. Files that couldn't be read are counted in the Unreadable
+// column of a row of their own, so that they aren't added to the drawings.
+func rollupRows(rollup *Rollup) [][]string {
+	rows := [][]string{{"Directory", "Depth", "Header", "Version", "Release", "Drawings", "Bytes", "Unreadable"}}
+	for _, dir := range rollup.Directories() {
+		depth := strconv.Itoa(dir.Depth)
+		for _, version := range dir.SortedVersions() {
+			totals := dir.Versions[version]
+			rows = append(rows, []string{
+				dir.Path,
+				depth,
+				version.String(),
+				version.ReleaseName(),
+				strconv.Itoa(version.Release()),
+				strconv.Itoa(totals.Drawings),
+				strconv.FormatInt(totals.Bytes, 10),
+				"0",
+			})
+		}
+		if dir.Failed > 0 {
+			rows = append(rows, []string{dir.Path, depth, "(unreadable)", "", "", "0", "0", strconv.Itoa(dir.Failed)})
+		}
+	}
+	return rows
+}
+
+// writeRollupTSV writes the rollup table as tab-separated values.
+func writeRollupTSV(w io.Writer, rollup *Rollup) error {
+	for _, row := range rollupRows(rollup) {
+		if _, err := io.WriteString(w, strings.Join(singleLine(row), "\t")+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeRollupCSV writes the rollup table as comma-separated values.
+func writeRollupCSV(w io.Writer, rollup *Rollup) error {
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+	cw.WriteAll(rollupRows(rollup))
+	return cw.Error()
+}
+
+// formatBytes returns n as a size in bytes with a binary unit prefix, such
+// as "1.5 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, prefix := float64(n)/unit, 0
+	for value >= unit && prefix < 4 {
+		value /= unit
+		prefix++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTP"[prefix])
+}
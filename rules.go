@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Rules decide which directories a scan descends into and which files it
+// reads. They are written one per line, in the style of .gitignore files:
+//
+//	# Old material that nobody needs to hear about
+//	**/_Archive/**
+//	**/Xref-Old/
+//	/Scratch/
+//	*.sv$
+//	re:\\Temp\d+\\
+//
+// Each line excludes the paths it matches, unless it starts with "!", in
+// which case it includes them. A pattern ending in a slash only matches
+// directories. A pattern starting with "re:" is a regular expression that is
+// matched against the full path and the name of each file and directory;
+// the others are matched like the patterns of a policy, against the path
+// relative to the scanned root. A pattern starting with a slash is anchored
+// to the root, so "/Scratch/" matches the Scratch folder in the root but not
+// those further down. Patterns and regular expressions ignore case.
+//
+// The last rule that matches a path decides whether it is included, and the
+// rules added by DefaultRules come first. A directory is walked unless a rule
+// excludes it, but a file is only read if a rule includes it. As with
+// .gitignore, nothing inside an excluded directory can be included again.
+type Rules struct {
+	list []Rule
+}
+
+// Rule is a single include or exclude rule.
+type Rule struct {
+	Include  bool   // Whether the rule includes the paths it matches
+	Pattern  string // The glob or regular expression, without its prefixes
+	DirOnly  bool   // Whether the rule only matches directories
+	Anchored bool   // Whether the pattern only matches from the root
+	Source   string // Where the rule came from, such as "rules.txt:3"
+
+	re *regexp.Regexp
+}
+
+// defaultRules are the rules that every set of rules starts with. They read
+// DWG and DXF files and skip system files and folders.
+var defaultRules = []string{
+	"!*.dwg",
+	"!*.dxf",
+	"System Volume Information/",
+	"$RECYCLE.BIN/",
+	"pagefile.sys",
+	"swapfile.sys",
+}
+
+// DefaultRules returns the built-in rules, to which more can be added.
+func DefaultRules() *Rules {
+	r := new(Rules)
+	for _, line := range defaultRules {
+		if err := r.Add(line, "default"); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// DefaultRulesPath returns the location of the rules file in the user's
+// configuration directory, which is loaded when no other is given.
+func DefaultRulesPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cadscan", "rules.txt"), nil
+}
+
+// ParseRule parses a rule written as a line of a rules file. Source
+// describes where the line came from.
+func ParseRule(line, source string) (Rule, error) {
+	rule := Rule{Source: source}
+
+	text := strings.TrimSpace(line)
+	if strings.HasPrefix(text, "!") {
+		rule.Include = true
+		text = text[1:]
+	}
+
+	if strings.HasPrefix(text, "re:") {
+		re, err := regexp.Compile("(?i)" + text[3:])
+		if err != nil {
+			return Rule{}, fmt.Errorf("%s: invalid regular expression: %v", source, err)
+		}
+		rule.Pattern, rule.re = text[3:], re
+		return rule, nil
+	}
+
+	if strings.HasSuffix(text, "/") {
+		rule.DirOnly = true
+		text = strings.TrimSuffix(text, "/")
+	}
+	if strings.HasPrefix(text, "/") {
+		rule.Anchored = true
+		text = strings.TrimPrefix(text, "/")
+	}
+	if text == "" {
+		return Rule{}, fmt.Errorf("%s: the rule has no pattern", source)
+	}
+	if _, err := path.Match(text, ""); err != nil {
+		return Rule{}, fmt.Errorf("%s: malformed pattern \"%s\"", source, text)
+	}
+	rule.Pattern = strings.ToLower(text)
+
+	return rule, nil
+}
+
+// Add parses line as a rule and adds it to r. Blank lines and lines starting
+// with "#" are ignored.
+func (r *Rules) Add(line, source string) error {
+	if text := strings.TrimSpace(line); text == "" || strings.HasPrefix(text, "#") {
+		return nil
+	}
+	rule, err := ParseRule(line, source)
+	if err != nil {
+		return err
+	}
+	r.list = append(r.list, rule)
+	return nil
+}
+
+// Load adds the rules in the file with the given name to r.
+func (r *Rules) Load(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		if err := r.Add(scanner.Text(), fmt.Sprintf("%s:%d", name, n)); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Match returns the last rule that matches path, which was found while
+// walking root, or false if there isn't one. If dir is true the path is a
+// directory.
+func (r *Rules) Match(root, path string, dir bool) (Rule, bool) {
+	rel, name := relativePath(root, path), filepath.Base(path)
+
+	for i := len(r.list) - 1; i >= 0; i-- {
+		if rule := r.list[i]; rule.matches(path, name, rel, dir) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// SkipDir reports whether the walk of root should skip the directory at
+// path, along with the rule that excludes it.
+func (r *Rules) SkipDir(root, path string) (Rule, bool) {
+	rule, ok := r.Match(root, path, true)
+	return rule, ok && !rule.Include
+}
+
+// SkipDirAnywhere reports whether walks of every root that contains the
+// directory at path would skip it, as for a folder tree that roots are
+// chosen from. Top is the folder at the top of the tree.
+//
+// Anchored rules, and other patterns that match from the root, can't decide
+// that. Excluding ones are ignored, and a directory that an including one
+// follows is never skipped, as the rule might include it again.
+func (r *Rules) SkipDirAnywhere(top, path string) bool {
+	rel, name := relativePath(top, path), filepath.Base(path)
+
+	for i := len(r.list) - 1; i >= 0; i-- {
+		rule := r.list[i]
+		if rule.fromRoot() {
+			if rule.Include {
+				return false
+			}
+			continue
+		}
+		if rule.matches(path, name, rel, true) {
+			return !rule.Include
+		}
+	}
+	return false
+}
+
+// ReadFile reports whether the walk of root should read the file at path,
+// along with the rule that decided it. If no rule matches the file, it is
+// not read and the rule is empty.
+func (r *Rules) ReadFile(root, path string) (Rule, bool) {
+	rule, ok := r.Match(root, path, false)
+	return rule, ok && rule.Include
+}
+
+// relativePath returns path relative to root in the form that rules are
+// matched against.
+func relativePath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	return strings.ToLower(filepath.ToSlash(rel))
+}
+
+// fromRoot reports whether the paths the rule matches depend on the root of
+// the walk. That is the case for anchored patterns and for patterns with a
+// slash that don't start with "**/".
+func (rule Rule) fromRoot() bool {
+	if rule.re != nil {
+		return false
+	}
+	return rule.Anchored || (strings.Contains(rule.Pattern, "/") && !strings.HasPrefix(rule.Pattern, "**/"))
+}
+
+func (rule Rule) matches(path, name, rel string, dir bool) bool {
+	if rule.re != nil {
+		return rule.re.MatchString(path) || rule.re.MatchString(name)
+	}
+	if rule.DirOnly && !dir {
+		return false
+	}
+	if rule.Anchored {
+		return matchElements(strings.Split(rule.Pattern, "/"), strings.Split(rel, "/"))
+	}
+	return matchPattern(rule.Pattern, rel)
+}
+
+// String returns the rule as it would be written in a rules file.
+func (rule Rule) String() string {
+	var text string
+	if rule.Include {
+		text = "!"
+	}
+	if rule.re != nil {
+		return text + "re:" + rule.Pattern
+	}
+	if rule.Anchored {
+		text += "/"
+	}
+	text += rule.Pattern
+	if rule.DirOnly {
+		text += "/"
+	}
+	return text
+}
+
+// ruleArgs holds the rules given on the command line, in the order they
+// were given.
+type ruleArgs struct {
+	path        string // The rules file to load
+	defaultPath string
+	lines       []string
+	sources     []string
+}
+
+// register adds the -rules, -include and -exclude flags to flags.
+func (a *ruleArgs) register(flags *flag.FlagSet) {
+	a.defaultPath, _ = DefaultRulesPath()
+	flags.StringVar(&a.path, "rules", a.defaultPath, "load include and exclude rules from `file`")
+	flags.Var(ruleFlag{a, true}, "include", "read files matching `pattern`, which may be repeated")
+	flags.Var(ruleFlag{a, false}, "exclude", "skip files and folders matching `pattern`, which may be repeated")
+}
+
+// load returns the default rules followed by those in the rules file and
+// then those given by -include and -exclude. The default rules file doesn't
+// have to exist.
+func (a *ruleArgs) load() (*Rules, error) {
+	rules := DefaultRules()
+	if a.path != "" {
+		err := rules.Load(a.path)
+		if err != nil && (a.path != a.defaultPath || !errors.Is(err, os.ErrNotExist)) {
+			return nil, err
+		}
+	}
+	for i, line := range a.lines {
+		if err := rules.Add(line, a.sources[i]); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// ruleFlag is a flag.Value that adds an include or exclude rule to a
+// ruleArgs each time it is set.
+type ruleFlag struct {
+	args    *ruleArgs
+	include bool
+}
+
+func (f ruleFlag) String() string {
+	return ""
+}
+
+func (f ruleFlag) Set(value string) error {
+	source, line := "-exclude "+value, value
+	if f.include {
+		source, line = "-include "+value, "!"+value
+	}
+	if _, err := ParseRule(line, source); err != nil {
+		return err
+	}
+	f.args.lines = append(f.args.lines, line)
+	f.args.sources = append(f.args.sources, source)
+	return nil
+}
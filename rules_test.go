@@ -0,0 +1,208 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		line string
+		want Rule
+		err  string
+	}{
+		{"*.DWG", Rule{Pattern: "*.dwg"}, ""},
+		{"!*.dwt", Rule{Include: true, Pattern: "*.dwt"}, ""},
+		{"  **/Xref-Old/  ", Rule{Pattern: "**/xref-old", DirOnly: true}, ""},
+		{"/Scratch/", Rule{Pattern: "scratch", DirOnly: true, Anchored: true}, ""},
+		{"!/Projects/*.dwg", Rule{Include: true, Pattern: "projects/*.dwg", Anchored: true}, ""},
+		{`re:\\Temp\d+\\`, Rule{Pattern: `\\Temp\d+\\`}, ""},
+		{"/", Rule{}, "has no pattern"},
+		{"!", Rule{}, "has no pattern"},
+		{"[a-", Rule{}, "malformed pattern"},
+		{"re:(", Rule{}, "invalid regular expression"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := ParseRule(tt.line, "test")
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("ParseRule() error = %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRule() error = %v", err)
+			}
+			got.re = nil
+			tt.want.Source = "test"
+			if got != tt.want {
+				t.Errorf("ParseRule() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleString(t *testing.T) {
+	for _, line := range []string{"*.dwg", "!*.dwt", "**/xref-old/", "/scratch/", "!/projects/*.dwg", `re:\\Temp\d+\\`} {
+		rule, err := ParseRule(line, "test")
+		if err != nil {
+			t.Fatalf("ParseRule(%q) error = %v", line, err)
+		}
+		if got := rule.String(); got != line {
+			t.Errorf("ParseRule(%q).String() = %q", line, got)
+		}
+	}
+}
+
+func TestRulesMatch(t *testing.T) {
+	rules := DefaultRules()
+	for _, line := range []string{
+		"/Scratch/",
+		"**/Xref-Old/",
+		"Archive",
+		"!/Archive/Keep.dwg",
+		`re:^Temp\d+$`,
+		"*.sv$",
+	} {
+		if err := rules.Add(line, "test"); err != nil {
+			t.Fatalf("Add(%q) error = %v", line, err)
+		}
+	}
+	root := filepath.Join("srv", "projects")
+	p := func(elem ...string) string {
+		return filepath.Join(append([]string{root}, elem...)...)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		dir  bool
+		rule string // The rule that matches, or "" for none
+	}{
+		{"Drawing", p("1042", "site.dwg"), false, "!*.dwg"},
+		{"UpperCase", p("1042", "SITE.DWG"), false, "!*.dwg"},
+		{"Other", p("1042", "notes.txt"), false, ""},
+		{"AnchoredDir", p("Scratch"), true, "/scratch/"},
+		{"AnchoredDeeper", p("1042", "Scratch"), true, ""},
+		{"AnchoredFile", p("scratch"), false, ""},
+		{"DirOnly", p("1042", "Xref-Old"), true, "**/xref-old/"},
+		{"DirOnlyFile", p("1042", "Xref-Old"), false, ""},
+		{"Unanchored", p("1042", "archive"), true, "archive"},
+		{"AnchoredInclude", p("Archive", "Keep.dwg"), false, "!/archive/keep.dwg"},
+		{"AnchoredIncludeDeeper", p("1042", "Archive", "Keep.dwg"), false, "!*.dwg"},
+		{"Regexp", p("Temp12"), true, `re:^Temp\d+$`},
+		{"LastWins", p("1042", "site.sv$"), false, "*.sv$"},
+		{"System", p("$RECYCLE.BIN"), true, "$recycle.bin/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := rules.Match(root, tt.path, tt.dir)
+			if got := rule.String(); ok != (tt.rule != "") || got != tt.rule {
+				t.Errorf("Match(%q) = %q, %v, want %q", tt.path, got, ok, tt.rule)
+			}
+		})
+	}
+}
+
+func TestRulesSkipDirAndReadFile(t *testing.T) {
+	rules := DefaultRules()
+	for _, line := range []string{"/Scratch/", "Old*.dwg"} {
+		if err := rules.Add(line, "test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root := filepath.Join("srv", "projects")
+
+	tests := []struct {
+		path string
+		dir  bool
+		want bool // Whether the directory is skipped or the file read
+	}{
+		{filepath.Join(root, "Scratch"), true, true},
+		{filepath.Join(root, "1042", "Scratch"), true, false},
+		{filepath.Join(root, "site.dwg"), false, true},
+		{filepath.Join(root, "Old site.dwg"), false, false},
+		{filepath.Join(root, "notes.txt"), false, false},
+	}
+
+	for _, tt := range tests {
+		var got bool
+		if tt.dir {
+			_, got = rules.SkipDir(root, tt.path)
+		} else {
+			_, got = rules.ReadFile(root, tt.path)
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRulesSkipDirAnywhere(t *testing.T) {
+	top := filepath.Join("srv", "share")
+	p := func(elem ...string) string {
+		return filepath.Join(append([]string{top}, elem...)...)
+	}
+
+	tests := []struct {
+		name  string
+		rules []string
+		path  string
+		want  bool
+	}{
+		{"Name", []string{"**/Xref-Old/"}, p("1042", "Xref-Old"), true},
+		{"NameAtTop", []string{"Scratch/"}, p("Scratch"), true},
+		{"Regexp", []string{`re:^Temp\d+$`}, p("1042", "Temp12"), true},
+		{"Anchored", []string{"/Scratch/"}, p("Scratch"), false},
+		{"FromRoot", []string{"Projects/Old/"}, p("Projects", "Old"), false},
+		{"Included", []string{"**/Old/", "!**/Old/"}, p("1042", "Old"), false},
+		{"AnchoredInclude", []string{"**/Old/", "!/Projects/Old/"}, p("1042", "Old"), false},
+		{"EarlierAnchoredInclude", []string{"!/Projects/Old/", "**/Old/"}, p("1042", "Old"), true},
+		{"System", nil, p("$RECYCLE.BIN"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := DefaultRules()
+			for _, line := range tt.rules {
+				if err := rules.Add(line, "test"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := rules.SkipDirAnywhere(top, tt.path); got != tt.want {
+				t.Errorf("SkipDirAnywhere(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRulesLoad(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "rules.txt")
+	text := "# Comment\n\n/Scratch/\n!*.dwt\n"
+	if err := os.WriteFile(name, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := new(Rules)
+	if err := rules.Load(name); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(rules.list) != 2 {
+		t.Fatalf("Load() added %d rules, want 2", len(rules.list))
+	}
+	if source := rules.list[1].Source; source != name+":4" {
+		t.Errorf("Source = %q, want %q", source, name+":4")
+	}
+
+	if err := os.WriteFile(name, []byte("*.dwg\n[a-\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := new(Rules).Load(name); err == nil || !strings.Contains(err.Error(), name+":2") {
+		t.Errorf("Load() error = %v, want one naming line 2", err)
+	}
+}
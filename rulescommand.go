@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// runRules reports what the include and exclude rules would do to the files
+// and folders in a directory, without reading any drawings.
+func runRules(args []string) int {
+	flags := flag.NewFlagSet("rules", flag.ContinueOnError)
+	var ruleArgs ruleArgs
+	ruleArgs.register(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: cadscan rules [flags] <root> [path...]\n\n")
+		fmt.Fprintf(flags.Output(), "With paths, explains whether a scan of root would read each of them and\n")
+		fmt.Fprintf(flags.Output(), "which rule decided it. Without, lists the folders and files a scan of root\n")
+		fmt.Fprintf(flags.Output(), "would skip.\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	root, paths := flags.Arg(0), flags.Args()[1:]
+
+	rules, err := ruleArgs.load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to load the rules: %v\n", err)
+		return 2
+	}
+
+	if len(paths) > 0 {
+		for _, path := range paths {
+			fmt.Printf("%s: %s\n", path, explainRules(rules, root, path))
+		}
+		return 0
+	}
+
+	if err := writeExclusions(os.Stdout, rules, root); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to walk %s: %v\n", root, err)
+		return 1
+	}
+	return 0
+}
+
+// explainRules describes whether a scan of root would read the file or walk
+// the directory at path, and why.
+func explainRules(rules *Rules, root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Sprintf("not within %s", root)
+	}
+
+	// A path inside an excluded folder is never reached
+	dir := root
+	elems := strings.Split(rel, string(filepath.Separator))
+	for _, elem := range elems[:len(elems)-1] {
+		dir = filepath.Join(dir, elem)
+		if rule, skip := rules.SkipDir(root, dir); skip {
+			return fmt.Sprintf("skipped, because the folder %s is excluded by %s", dir, describeRule(rule))
+		}
+	}
+
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		if rule, skip := rules.SkipDir(root, path); skip && rel != "." {
+			return fmt.Sprintf("folder excluded by %s", describeRule(rule))
+		}
+		return "folder walked"
+	}
+
+	rule, read := rules.ReadFile(root, path)
+	switch {
+	case read:
+		return fmt.Sprintf("read, as it is included by %s", describeRule(rule))
+	case rule.Source != "":
+		return fmt.Sprintf("skipped, as it is excluded by %s", describeRule(rule))
+	default:
+		return "skipped, as no rule includes it"
+	}
+}
+
+// describeRule returns the text of rule and where it came from.
+func describeRule(rule Rule) string {
+	return fmt.Sprintf("\"%s\" (%s)", rule, rule.Source)
+}
+
+// writeExclusions walks root as a scan would and writes the folders and
+// files that the rules exclude to w in the order a scan finds them, followed by
+// totals. Files that no rule matches aren't listed, as most of them aren't
+// drawings.
+func writeExclusions(w io.Writer, rules *Rules, root string) error {
+	var (
+		mutex             sync.Mutex
+		found             []File                // Excluded and unreadable paths
+		lines             = map[string]string{} // What to write for each of them
+		dirs, files, read int
+	)
+	note := func(path, line string, count *int) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		found = append(found, File{Path: path, Root: root})
+		lines[path] = line
+		if count != nil {
+			*count++
+		}
+	}
+
+	// The walker lists directories ahead of the walk, so the rules are
+	// evaluated out of order and the exclusions sorted afterwards
+	walker := treeWalker{
+		workers: DefaultWalkers,
+		skipDir: func(path string) bool {
+			rule, skip := rules.SkipDir(root, path)
+			if skip {
+				note(path, fmt.Sprintf("%s%c: excluded by %s", path, filepath.Separator, describeRule(rule)), &dirs)
+			}
+			return skip
+		},
+		wantFile: func(path string) bool {
+			rule, ok := rules.ReadFile(root, path)
+			if !ok && rule.Source != "" {
+				note(path, fmt.Sprintf("%s: excluded by %s", path, describeRule(rule)), &files)
+			}
+			return ok
+		},
+	}
+	err := walker.walk(context.Background(), root, func(path string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			note(path, fmt.Sprintf("%s: %v", path, err), nil)
+		case !d.IsDir():
+			read++
+		}
+		return nil
+	})
+
+	sortWalkOrder([]string{root}, found)
+	for _, file := range found {
+		fmt.Fprintln(w, lines[file.Path])
+	}
+	fmt.Fprintf(w, "%d files would be read. %d folders and %d files are excluded.\n", read, dirs, files)
+	return err
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// rulesTree creates a small tree of drawings and other files in a temporary
+// directory, along with rules that exclude parts of it.
+func rulesTree(t *testing.T) (root string, rules *Rules) {
+	root = t.TempDir()
+	writeTree(t, root, map[string]string{
+		"a.dwg":                 "",
+		"notes.txt":             "",
+		"1042/site.dwg":         "",
+		"1042/site.bak.dwg":     "",
+		"1042/Scratch/test.dwg": "",
+		"Scratch/test.dwg":      "",
+		"z/plan.dwg":            "",
+	})
+
+	rules = DefaultRules()
+	for _, line := range []string{"/Scratch/", "*.bak.dwg"} {
+		if err := rules.Add(line, "test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root, rules
+}
+
+func TestExplainRules(t *testing.T) {
+	root, rules := rulesTree(t)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(root, "a.dwg"), `read, as it is included by "!*.dwg" (default)`},
+		{filepath.Join(root, "notes.txt"), "skipped, as no rule includes it"},
+		{filepath.Join(root, "1042", "site.bak.dwg"), `skipped, as it is excluded by "*.bak.dwg" (test)`},
+		{filepath.Join(root, "Scratch"), `folder excluded by "/scratch/" (test)`},
+		{filepath.Join(root, "Scratch", "test.dwg"), "skipped, because the folder " + filepath.Join(root, "Scratch") + " is excluded"},
+		{filepath.Join(root, "1042", "Scratch"), "folder walked"},
+		{root, "folder walked"},
+		{filepath.Dir(root), "not within"},
+	}
+
+	for _, tt := range tests {
+		if got := explainRules(rules, root, tt.path); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("explainRules(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWriteExclusions(t *testing.T) {
+	root, rules := rulesTree(t)
+
+	var out strings.Builder
+	if err := writeExclusions(&out, rules, root); err != nil {
+		t.Fatalf("writeExclusions() error = %v", err)
+	}
+
+	// Exclusions are listed in walk order, however they were found
+	want := []string{
+		filepath.Join(root, "1042", "site.bak.dwg") + `: excluded by "*.bak.dwg" (test)`,
+		filepath.Join(root, "Scratch") + string(filepath.Separator) + `: excluded by "/scratch/" (test)`,
+		"4 files would be read. 1 folders and 1 files are excluded.",
+	}
+	if got := out.String(); got != strings.Join(want, "\n")+"\n" {
+		t.Errorf("writeExclusions() wrote:\n%swant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
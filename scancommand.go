@@ -0,0 +1,322 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// runScan scans one or more directories without the graphical interface
+// and writes the results to standard output.
+func runScan(args []string) int {
+	flags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	var scanArgs scanArgs
+	scanArgs.register(flags, true)
+	format := flags.String("format", "text", "output format: text, tsv, csv, json, ndjson, html or xlsx")
+	snapshotPath := flags.String("snapshot", "", "also save the results as a snapshot `file` for the diff command")
+	order := flags.String("order", "path", "order of the results: path, completion, or sorted to collect them as they complete and then sort them by path")
+	filterText := flags.String("filter", "", "only output the results that satisfy the filter `expression`")
+	policyPath := flags.String("policy", "", "check drawing versions against the policy in `file`, exiting with status 3 on violations")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: cadscan scan [flags] <root>...\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	roots := flags.Args()
+	if len(roots) == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	scanner, options, status := scanArgs.open(roots)
+	if status != 0 {
+		return status
+	}
+
+	out, err := newResultWriter(*format, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	var sink Sink = out
+
+	var sorted bool
+	switch strings.ToLower(*order) {
+	case "path":
+	case "completion":
+		options.Unordered = true
+	case "sorted":
+		options.Unordered, sorted = true, true
+	default:
+		fmt.Fprintf(os.Stderr, "unknown result order \"%s\"\n", *order)
+		return 2
+	}
+
+	var filtered *filterSink
+	if *filterText != "" {
+		filter, err := ParseFilter(*filterText)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			fmt.Fprintf(os.Stderr, "Filters can refer to: %s\n", strings.Join(FilterFields(), ", "))
+			return 2
+		}
+		filtered = &filterSink{Sink: out, filter: filter}
+		sink = filtered
+		if j, ok := out.(*jsonWriter); ok {
+			j.setFilter(filter)
+		}
+	}
+
+	var snapshot *jsonWriter
+	if *snapshotPath != "" {
+		f, err := os.Create(*snapshotPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to create the snapshot: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		snapshot = newJSONWriter(f)
+		sink = MultiSink(sink, snapshot)
+	}
+	var checker *policyChecker
+	if *policyPath != "" {
+		policy, err := LoadPolicy(*policyPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 2
+		}
+		checker = &policyChecker{policy: policy}
+		sink = MultiSink(sink, checker)
+	}
+	if sorted {
+		sink = SortedSink(sink)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	summary, err := scanAndWait(scanner, roots, sink, options, interrupt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Scan failed: %v\n", err)
+		status = 1
+	}
+
+	if err := out.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write results: %v\n", err)
+		return 1
+	}
+
+	if filtered != nil && filtered.err != nil {
+		fmt.Fprintf(os.Stderr, "Some results were left out because the %v\n", filtered.err)
+	}
+
+	if snapshot != nil {
+		if err := snapshot.Flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save the snapshot: %v\n", err)
+			status = 1
+		}
+	}
+
+	saveCache(options)
+
+	if err == nil {
+		writeSummary(os.Stderr, summary)
+	}
+
+	if checker != nil {
+		writeViolations(os.Stderr, checker)
+		if len(checker.violations) > 0 && status == 0 {
+			status = 3
+		}
+	}
+
+	return status
+}
+
+// scanArgs holds the flags shared by the commands that scan directories.
+type scanArgs struct {
+	workers  int
+	walkers  int
+	cache    string // The scan cache file, if the command uses one
+	rescan   bool
+	timeout  time.Duration
+	slow     time.Duration
+	progress bool
+	rules    ruleArgs
+}
+
+// register adds the flags that control scanning to flags, including the
+// -cache and -rescan flags if cached is true.
+func (a *scanArgs) register(flags *flag.FlagSet, cached bool) {
+	flags.IntVar(&a.workers, "workers", DefaultWorkers, "number of drawings to read concurrently")
+	flags.IntVar(&a.walkers, "walkers", DefaultWalkers, "number of directories to list concurrently")
+	if cached {
+		defaultCache, _ := DefaultCachePath()
+		flags.StringVar(&a.cache, "cache", defaultCache, "scan cache `file`, or \"\" to read every drawing without a cache")
+		flags.BoolVar(&a.rescan, "rescan", false, "read every drawing again and refresh the scan cache")
+	}
+	flags.DurationVar(&a.timeout, "timeout", DefaultReadTimeout, "give up on drawings that take longer than this to read, or 0 to wait for them")
+	flags.DurationVar(&a.slow, "slow", DefaultSlowThreshold, "list the drawings that take at least this long to read, or 0 to list only those that time out")
+	flags.BoolVar(&a.progress, "progress", false, "show the progress of each scan on standard error")
+	a.rules.register(flags)
+}
+
+// open checks the flags and roots, and returns a scanner and the options
+// for scanning roots. If they can't be used, the reason is written to
+// standard error and the exit status for the command is returned instead.
+func (a *scanArgs) open(roots []string) (*Scanner, ScanOptions, int) {
+	if a.workers < 1 {
+		fmt.Fprintf(os.Stderr, "The number of workers must be at least 1.\n")
+		return nil, ScanOptions{}, 2
+	}
+
+	if a.walkers < 1 {
+		fmt.Fprintf(os.Stderr, "The number of walkers must be at least 1.\n")
+		return nil, ScanOptions{}, 2
+	}
+
+	rules, err := a.rules.load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to load the rules: %v\n", err)
+		return nil, ScanOptions{}, 2
+	}
+
+	for _, root := range roots {
+		if _, err := os.Stat(root); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to scan %s: %v\n", root, err)
+			return nil, ScanOptions{}, 1
+		}
+	}
+
+	options := ScanOptions{
+		Rescan:        a.rescan,
+		Rules:         rules,
+		Timeout:       a.timeout,
+		SlowThreshold: a.slow,
+		Walkers:       a.walkers,
+	}
+	if a.progress {
+		options.Progress = progressLine(os.Stderr)
+	}
+	if a.cache != "" {
+		if options.Cache, err = OpenCache(a.cache); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to open the scan cache: %v\n", err)
+			return nil, ScanOptions{}, 1
+		}
+	}
+
+	return NewScanner(a.workers), options, 0
+}
+
+// saveCache saves the scan cache of options, if it has one. Failing to save
+// it is reported but doesn't affect the outcome of the command.
+func saveCache(options ScanOptions) {
+	if options.Cache == nil {
+		return
+	}
+	if err := options.Cache.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save the scan cache: %v\n", err)
+	}
+}
+
+// writeViolations reports the outcome of the checks made by checker to w.
+func writeViolations(w io.Writer, checker *policyChecker) {
+	if len(checker.violations) == 0 {
+		fmt.Fprintf(w, "All %d files covered by the policy comply with it.\n", checker.checked)
+	} else {
+		fmt.Fprintf(w, "%d of %d files covered by the policy violate it:\n", len(checker.violations), checker.checked)
+		for _, v := range checker.violations {
+			fmt.Fprintf(w, "  %s: %s\n", v.File.Path, v.Message)
+		}
+	}
+
+	if len(checker.unlisted) > 0 {
+		fmt.Fprintf(w, "Policy coverage is incomplete: the drawings in %d folders could not be checked:\n", len(checker.unlisted))
+		for _, dir := range checker.unlisted {
+			fmt.Fprintf(w, "  %s: %s\n", dir.Path, dir.Reason)
+		}
+	}
+}
+
+// writeSummary writes the totals of a scan, and those of each of its roots
+// if it had several, to w.
+func writeSummary(w io.Writer, summary Summary) {
+	duration := summary.Duration().Round(time.Millisecond)
+	if len(summary.Roots) == 1 {
+		fmt.Fprintf(w, "Scanned %s in %v: %s.\n", summary.Roots[0], duration, summary.Totals)
+	} else {
+		for i, root := range summary.Roots {
+			fmt.Fprintf(w, "Scanned %s: %s.\n", root, summary.PerRoot[i])
+		}
+		fmt.Fprintf(w, "Scanned %d roots in %v: %s.\n", len(summary.Roots), duration, summary.Totals)
+	}
+
+	for i, root := range summary.Roots {
+		if t := summary.PerRoot[i]; !t.Complete() {
+			fmt.Fprintf(w, "Coverage of %s is incomplete: %d files or folders could not be read.\n", root, t.Failed())
+		}
+	}
+
+	if len(summary.Slow) > 0 {
+		fmt.Fprintf(w, "%d drawings were slow to read:\n", len(summary.Slow))
+		for _, file := range summary.Slow {
+			note := ""
+			if file.Reason == ReasonTimeout {
+				note = " (timed out)"
+			}
+			fmt.Fprintf(w, "  %s: %v%s\n", file.Path, file.ReadTime.Round(time.Millisecond), note)
+		}
+	}
+}
+
+// scanAndWait scans roots with the given options, recording the results to
+// sink, and waits for the scan to finish. If an interrupt arrives first the
+// scan is stopped.
+//
+// It returns the summary of the scan and the error that prevented it from
+// completing, if any.
+func scanAndWait(scanner *Scanner, roots []string, sink Sink, options ScanOptions, interrupt <-chan os.Signal) (Summary, error) {
+	status := &scanStatus{done: make(chan struct{})}
+	scanner.Scan(roots, MultiSink(sink, status), options)
+
+	select {
+	case <-status.done:
+	case <-interrupt:
+		scanner.Stop()
+		<-status.done
+	}
+
+	return status.summary, status.err
+}
+
+// scanStatus is a Sink that records the outcome of a scan.
+type scanStatus struct {
+	done    chan struct{}
+	err     error
+	summary Summary
+}
+
+func (s *scanStatus) Start(roots []string) {}
+
+func (s *scanStatus) Append(files ...File) {}
+
+func (s *scanStatus) Error(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *scanStatus) Finish(summary Summary) {
+	s.summary = summary
+	close(s.done)
+}
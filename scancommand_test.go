@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runCaptured runs the named command with args and returns what it wrote to
+// standard output and standard error, along with its exit status.
+func runCaptured(t *testing.T, name string, args ...string) (stdout, stderr string, status int) {
+	t.Helper()
+	dir := t.TempDir()
+	outFile, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer outFile.Close()
+	errFile, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer errFile.Close()
+
+	oldOut, oldErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = outFile, errFile
+	status = runCommand(name, args)
+	os.Stdout, os.Stderr = oldOut, oldErr
+
+	out, err := os.ReadFile(outFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	errOut, err := os.ReadFile(errFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), string(errOut), status
+}
+
+// commandTree creates a tree with two drawings, a file that isn't a drawing
+// and a file that isn't read, and returns its root.
+func commandTree(t *testing.T) string {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"old.dwg":        "AC1009",
+		"1042/site.dwg":  "AC1015" + strings.Repeat("\x00", 74),
+		"1042/notes.dwg": "Meeting notes",
+		"readme.txt":     "Not a drawing",
+	})
+	return root
+}
+
+func TestRunScan(t *testing.T) {
+	root := commandTree(t)
+	p := func(elem ...string) string {
+		return filepath.Join(append([]string{root}, elem...)...)
+	}
+	policy := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(policy, []byte(`{"rules": [{"pattern": "**", "min": "AC1015"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Scans don't load the user's rules or cache
+	scan := func(args ...string) []string {
+		return append([]string{"-rules", "", "-cache", ""}, args...)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		status int
+		stdout []string // Lines of standard output, in any order
+		stderr string   // Text that standard error contains
+	}{
+		{
+			name:   "TSV",
+			args:   scan("-format", "tsv", root),
+			stdout: []string{"", p("1042", "notes.dwg"), p("1042", "site.dwg"), p("old.dwg")},
+			stderr: "2 drawings read, 1 could not be read (1 not a drawing)",
+		},
+		{
+			name:   "NDJSON",
+			args:   scan("-format", "ndjson", "-order", "completion", root),
+			stdout: []string{p("1042", "notes.dwg"), p("1042", "site.dwg"), p("old.dwg")},
+		},
+		{
+			name:   "Filter",
+			args:   scan("-format", "tsv", "-filter", `header == "AC1015"`, root),
+			stdout: []string{"", p("1042", "site.dwg")},
+		},
+		{
+			name:   "Excluded",
+			args:   scan("-format", "tsv", "-exclude", "1042/", root),
+			stdout: []string{"", p("old.dwg")},
+		},
+		{
+			name:   "PolicyViolated",
+			args:   scan("-format", "tsv", "-policy", policy, root),
+			status: 3,
+			stdout: []string{"", p("1042", "notes.dwg"), p("1042", "site.dwg"), p("old.dwg")},
+			stderr: "2 of 3 files covered by the policy violate it",
+		},
+		{name: "NoRoots", args: scan("-format", "tsv"), status: 2, stderr: "Usage: cadscan scan"},
+		{name: "UnknownFlag", args: scan("-colour", root), status: 2, stderr: "flag provided but not defined"},
+		{name: "UnknownFormat", args: scan("-format", "pdf", root), status: 2, stderr: `unknown output format "pdf"`},
+		{name: "UnknownOrder", args: scan("-order", "size", root), status: 2, stderr: `unknown result order "size"`},
+		{name: "NoWorkers", args: scan("-workers", "0", root), status: 2, stderr: "at least 1"},
+		{name: "BadFilter", args: scan("-filter", "version ~", root), status: 2, stderr: "Filters can refer to"},
+		{name: "MissingRoot", args: scan(p("missing")), status: 1, stderr: "Unable to scan"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, status := runCaptured(t, "scan", tt.args...)
+			if status != tt.status {
+				t.Fatalf("exit status = %d, want %d\nstderr:\n%s", status, tt.status, stderr)
+			}
+			if !strings.Contains(stderr, tt.stderr) {
+				t.Errorf("stderr doesn't contain %q:\n%s", tt.stderr, stderr)
+			}
+			if tt.stdout == nil {
+				if stdout != "" {
+					t.Errorf("stdout = %q, want nothing", stdout)
+				}
+				return
+			}
+
+			got := outputPaths(t, stdout)
+			want := make(map[string]bool)
+			for _, path := range tt.stdout {
+				want[path] = true
+			}
+			if len(got) != len(want) {
+				t.Fatalf("stdout has %d lines, want %d:\n%s", len(got), len(want), stdout)
+			}
+			for path := range want {
+				if !got[path] {
+					t.Errorf("stdout has no line for %q:\n%s", path, stdout)
+				}
+			}
+		})
+	}
+}
+
+// outputPaths returns the paths of the results in the scan output. Each
+// line is expected to be either a TSV row, whose first field is the path,
+// or an NDJSON object. The header row of TSV output is given an empty path.
+func outputPaths(t *testing.T, output string) map[string]bool {
+	paths := make(map[string]bool)
+	lines := bufio.NewScanner(strings.NewReader(output))
+	for lines.Scan() {
+		line := lines.Text()
+		if strings.HasPrefix(line, "{") {
+			var file jsonFile
+			if err := json.Unmarshal([]byte(line), &file); err != nil {
+				t.Fatalf("line %q isn't a JSON object: %v", line, err)
+			}
+			paths[file.Path] = true
+			continue
+		}
+		path, _, _ := strings.Cut(line, "\t")
+		if path == "File" {
+			path = ""
+		}
+		paths[path] = true
+	}
+	return paths
+}
@@ -1,14 +1,16 @@
+//go:build windows
+
 package main
 
 import (
 	"sort"
-	"strings"
 	"sync"
 
 	"github.com/lxn/walk"
 )
 
-// ScanModel is a view model for the scan results.
+// ScanModel is a view model for the scan results. It is a Sink, so it can
+// receive results directly from a Scanner.
 //
 // ScanModel is not threadsafe. Its operation should be managed by a single
 // goroutine, such as the Sync function.
@@ -17,9 +19,12 @@ type ScanModel struct {
 	walk.SorterBase
 	sortColumn int
 	sortOrder  walk.SortOrder
+	sorted     bool
 
-	mutex sync.RWMutex
-	files []File
+	mutex  sync.RWMutex
+	all    []File  // Every result, in the order they were found
+	files  []File  // The results shown, which satisfy the filter
+	filter *Filter // The filter results must satisfy to be shown, if any
 }
 
 // RowCount returns the number of rows in the model.
@@ -41,16 +46,10 @@ func (m *ScanModel) Value(row, col int) interface{} {
 
 	file := m.files[row]
 
-	switch col {
-	case 0:
-		return file.Path
-	case 1:
-		return file.Version.String()
-	case 2:
-		return file.Version.ReleaseName()
-	default:
+	if col < 0 || col >= len(resultColumns) {
 		return nil
 	}
+	return resultColumns[col].Value(file)
 }
 
 // Checked is called by the TableView to retrieve if a given row is checked.
@@ -69,8 +68,15 @@ func (m *ScanModel) Sort(col int, order walk.SortOrder) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	m.sortColumn, m.sortOrder = col, order
+	m.sortColumn, m.sortOrder, m.sorted = col, order, true
+	m.sortFiles()
 
+	return m.SorterBase.Sort(col, order)
+}
+
+// sortFiles sorts the results shown by the current sort column and order.
+// The caller must hold a lock on m.mutex.
+func (m *ScanModel) sortFiles() {
 	sort.SliceStable(m.files, func(i, j int) bool {
 		a, b := m.files[i], m.files[j]
 
@@ -82,25 +88,45 @@ func (m *ScanModel) Sort(col int, order walk.SortOrder) error {
 			return !ls
 		}
 
-		switch m.sortColumn {
-		case 0:
-			return c(strings.Compare(a.Path, b.Path) < 0)
-		case 1:
-			return c(a.Version.Release() < b.Version.Release())
-		case 2:
+		switch resultColumns[m.sortColumn].Title {
+		case "Format":
+			return c(a.Format < b.Format)
+		case "Header", "Version":
 			return c(a.Version.Release() < b.Version.Release())
+		case "Maint":
+			return c(maintenanceRelease(a) < maintenanceRelease(b))
+		case "Error":
+			return c(a.Reason < b.Reason)
+		default:
+			return c(resultColumns[m.sortColumn].Value(a) < resultColumns[m.sortColumn].Value(b))
 		}
-
-		panic("unexpected table sort column number")
 	})
+}
 
-	return m.SorterBase.Sort(col, order)
+// Start clears the model in preparation for a new scan.
+func (m *ScanModel) Start(roots []string) {
+	m.Clear()
+}
+
+// Error has no effect. Scan errors are reported by the window.
+func (m *ScanModel) Error(err error) {}
+
+// Finish puts the results, which may have been delivered as they completed,
+// back in the order the scan found them, unless they have been sorted by a
+// column.
+func (m *ScanModel) Finish(summary Summary) {
+	m.mutex.Lock()
+	sortWalkOrder(summary.Roots, m.all)
+	m.refilter()
+	m.mutex.Unlock()
+
+	m.PublishRowsReset()
 }
 
 // Clear removes all results from the model.
 func (m *ScanModel) Clear() {
 	m.mutex.Lock()
-	m.files = nil
+	m.all, m.files = nil, nil
 	m.mutex.Unlock()
 
 	m.PublishRowsReset()
@@ -113,8 +139,13 @@ func (m *ScanModel) Append(results ...File) {
 	}
 
 	m.mutex.Lock()
+	m.all = append(m.all, results...)
 	start := len(m.files)
-	m.files = append(m.files, results...)
+	for _, file := range results {
+		if m.matches(file) {
+			m.files = append(m.files, file)
+		}
+	}
 	end := len(m.files) - 1
 	m.mutex.Unlock()
 
@@ -123,7 +154,51 @@ func (m *ScanModel) Append(results ...File) {
 	}
 }
 
-// Results returns the current set of results in the model.
+// SetFilter shows only the results that satisfy filter, or every result if
+// filter is nil. Results the filter can't be evaluated for are hidden.
+func (m *ScanModel) SetFilter(filter *Filter) {
+	m.mutex.Lock()
+	m.filter = filter
+	m.refilter()
+	m.mutex.Unlock()
+
+	m.PublishRowsReset()
+}
+
+// refilter rebuilds the results shown from every result in the model. The
+// caller must hold a lock on m.mutex.
+func (m *ScanModel) refilter() {
+	m.files = nil
+	for _, file := range m.all {
+		if m.matches(file) {
+			m.files = append(m.files, file)
+		}
+	}
+	if m.sorted {
+		m.sortFiles()
+	}
+}
+
+// matches reports whether file satisfies the model's filter. The caller must
+// hold a lock on m.mutex.
+func (m *ScanModel) matches(file File) bool {
+	if m.filter == nil {
+		return true
+	}
+	ok, _ := m.filter.Match(file)
+	return ok
+}
+
+// Counts returns the number of results shown and the total number of
+// results in the model.
+func (m *ScanModel) Counts() (shown, total int) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return len(m.files), len(m.all)
+}
+
+// Results returns the results shown in the model.
 func (m *ScanModel) Results() []File {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
@@ -2,13 +2,39 @@ package main
 
 import (
 	"context"
-	"os"
+	"errors"
+	"fmt"
+	"io/fs"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// DefaultWorkers is the number of drawings a scanner reads concurrently
+// unless told otherwise.
+const DefaultWorkers = 32
+
+// DefaultReadTimeout is how long a drawing may take to read before it is
+// recorded as timed out, unless told otherwise.
+const DefaultReadTimeout = time.Minute
+
+// DefaultSlowThreshold is how long a drawing must take to read to be listed
+// as slow in the summary of a scan, unless told otherwise.
+const DefaultSlowThreshold = 10 * time.Second
+
+// maxAbandonedReads is the most reads that a scanner leaves running in the
+// background after they time out. Each holds a goroutine and usually a
+// thread blocked in the file system, so once there are this many the file
+// system is taken to be unavailable and the scan is stopped.
+const maxAbandonedReads = 256
+
+// errUnresponsive stops a scan that has abandoned too many reads.
+var errUnresponsive = errors.New("too many drawings have stopped responding, so the file system may be unavailable")
+
 // A token represents the right to perform work
 type token struct{}
 
@@ -17,24 +43,76 @@ type task struct {
 	result chan File
 }
 
-// A Scanner is responsible for scanning file systems for DWG files and
-// assessing their versions.
+// A Scanner is responsible for scanning file systems for DWG and DXF files
+// and assessing their versions.
 //
-// Scanner records its results to a ScanModel.
+// Scanner records the results of each scan to a Sink, such as a ScanModel.
 type Scanner struct {
-	model  *ScanModel
-	tokens chan token
+	workers int
+	tokens  chan token
+	read    func(path string) File                   // Reads a drawing, replaceable for tests
+	readDir func(name string) ([]fs.DirEntry, error) // Lists a directory if not nil, replaceable for tests
+
+	abandoned    atomic.Int32 // Number of timed out reads still running
+	maxAbandoned int32        // Number of abandoned reads at which scans stop
 
 	mutex   sync.Mutex
 	cancel  context.CancelFunc
 	stopped <-chan struct{}
 }
 
-// NewScanner returns a scanner that will write its output to the given model.
-func NewScanner(model *ScanModel, workers int) *Scanner {
+// ScanOptions control how a scan is performed.
+type ScanOptions struct {
+	// Cache holds the results of earlier scans. If it is not nil, drawings
+	// that haven't changed since they were cached aren't read again, and
+	// the cache is updated with the results of the scan.
+	Cache *Cache
+
+	// Rescan causes every drawing to be read even if the cache holds a
+	// result for it. The cache is still updated.
+	Rescan bool
+
+	// Rules decide which directories are walked and which files are read.
+	// If it is nil, DefaultRules are used.
+	Rules *Rules
+
+	// Progress, if not nil, is called with the scan's progress several
+	// times a second, and once more when it finishes. It is called from the
+	// scanning goroutine and should return quickly.
+	Progress func(Progress)
+
+	// Unordered delivers each result as soon as it is available, instead of
+	// in the order the files were found, so that a drawing that is slow to
+	// read doesn't hold up the results of the others. Wrap the sink with
+	// SortedSink to restore the order once the scan is complete.
+	Unordered bool
+
+	// Timeout limits how long each drawing may take to read, or is zero for
+	// no limit. Drawings that take longer are recorded with ReasonTimeout
+	// and their workers move on to other drawings. A read that never returns
+	// is abandoned, as it can't be interrupted, and if too many are left
+	// running the scan stops with an error.
+	Timeout time.Duration
+
+	// SlowThreshold, if not zero, is how long a drawing must take to read
+	// to be listed in the Slow files of the scan's summary. Drawings that
+	// time out are always listed.
+	SlowThreshold time.Duration
+
+	// Walkers is the number of directories that are listed at a time while
+	// the drawings found so far are read. If it is zero, DefaultWalkers is
+	// used.
+	Walkers int
+}
+
+// NewScanner returns a scanner that will read up to the given number of
+// drawings at a time.
+func NewScanner(workers int) *Scanner {
 	s := &Scanner{
-		model:  model,
-		tokens: make(chan token, workers),
+		workers:      workers,
+		tokens:       make(chan token, workers),
+		read:         readFile,
+		maxAbandoned: maxAbandonedReads,
 	}
 	for i := 0; i < workers; i++ {
 		s.tokens <- token{}
@@ -42,18 +120,21 @@ func NewScanner(model *ScanModel, workers int) *Scanner {
 	return s
 }
 
-// Scan causes the scanner to start scanning the given directory.
-func (s *Scanner) Scan(dir string, init, finished func()) {
+// Scan causes the scanner to start scanning the given directories and record
+// their results to sink as a single scan. Roots that are repeated or lie
+// within another root are only scanned once. Any scan already in progress is
+// stopped first.
+func (s *Scanner) Scan(roots []string, sink Sink, options ScanOptions) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
 	s.stop()
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancelCause(context.Background())
 	stopped := make(chan struct{})
-	s.cancel, s.stopped = cancel, stopped
+	s.cancel, s.stopped = func() { cancel(nil) }, stopped
 
-	go s.scan(ctx, stopped, dir, init, finished)
+	go s.scan(ctx, cancel, stopped, dedupeRoots(roots), sink, options)
 }
 
 // Stop cancels any scan that may be in-progress.
@@ -89,53 +170,136 @@ func (s *Scanner) stop() bool {
 	return true
 }
 
-func (s *Scanner) scan(ctx context.Context, done chan<- struct{}, dir string, init, finished func()) {
+func (s *Scanner) scan(ctx context.Context, cancel context.CancelCauseFunc, done chan<- struct{}, roots []string, sink Sink, options ScanOptions) {
 	defer close(done)
-	if finished != nil {
-		defer finished()
-	}
 
-	if init != nil {
-		init()
+	summary := newSummary(roots, s.workers)
+	defer func() {
+		summary.Finished = time.Now()
+		sort.SliceStable(summary.Slow, func(i, j int) bool {
+			return summary.Slow[i].ReadTime > summary.Slow[j].ReadTime
+		})
+		sink.Finish(summary)
+	}()
+
+	sink.Start(roots)
+
+	rules := options.Rules
+	if rules == nil {
+		rules = DefaultRules()
 	}
 
-	s.model.Clear()
+	counter := progressCounter{started: summary.Started}
+	counter.progress.Roots = roots
 
-	queue := make(chan string, 128)      // Ordered paths to be scanned
-	results := make(chan chan File, 128) // Ordered results
+	queue := make(chan File, 128) // Ordered paths to be scanned
+
+	// Results are delivered in order through a channel for each file, or
+	// in the order they complete through a single channel
+	var results chan chan File
+	var completed chan File
+	if options.Unordered {
+		completed = make(chan File, 128)
+	} else {
+		results = make(chan chan File, 128)
+	}
+
+	walkers := options.Walkers
+	if walkers == 0 {
+		walkers = DefaultWalkers
+	}
 
 	// Phase 1: Harvest paths from the file system
 	go func() {
 		defer close(queue)
-		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-			if ctx.Err() != nil {
-				return ctx.Err()
+		defer counter.walked.Store(true)
+		for _, root := range roots {
+			// The rules are evaluated once for each entry as its directory
+			// is listed, so the walk only reaches what will be scanned
+			skipDir := func(path string) bool {
+				_, skip := rules.SkipDir(root, path)
+				return skip
 			}
-
-			if err != nil || info == nil {
-				return filepath.SkipDir
+			wantFile := func(path string) bool {
+				_, read := rules.ReadFile(root, path)
+				return read
 			}
+			walker := treeWalker{workers: walkers, skipDir: skipDir, wantFile: wantFile, readDir: s.readDir}
+			walker.walk(ctx, root, func(path string, d fs.DirEntry, err error) error {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
 
-			name := info.Name()
-			if info.IsDir() {
-				if shouldExclude(name) {
-					return filepath.SkipDir
+				if err != nil {
+					// Record the failure and carry on with the rest of the tree.
+					// Only the root and directories that can't be listed fail
+					// here.
+					counter.found.Add(1)
+					queue <- File{Path: path, Root: root, Dir: true, Reason: classifyError(err), Err: err}
+					return nil
 				}
-				return nil
-			}
 
-			if isDrawingFile(name) {
-				queue <- path
-			}
+				if d.IsDir() {
+					counter.directories.Add(1)
+					return nil
+				}
 
-			return nil
-		})
+				counter.found.Add(1)
+				info, err := d.Info()
+				if err != nil {
+					queue <- File{Path: path, Root: root, Reason: classifyError(err), Err: err}
+					return nil
+				}
+				queue <- File{Path: path, Root: root, Size: info.Size(), ModTime: info.ModTime()}
+				return nil
+			})
+		}
 	}()
 
 	// Phase 2: Spawn workers for each path
+	seen := make(map[string]bool)         // Paths found, for pruning the cache
+	unlisted := make(map[string][]string) // Directories that failed to list, by root
 	go func() {
-		defer close(results)
-		for path := range queue {
+		var pending sync.WaitGroup // Workers that have yet to deliver a result
+		defer func() {
+			if completed != nil {
+				pending.Wait()
+				close(completed)
+			} else {
+				close(results)
+			}
+		}()
+
+		// pass delivers a result that doesn't need a worker
+		pass := func(file File) {
+			if completed != nil {
+				completed <- file
+				return
+			}
+			result := make(chan File, 1)
+			result <- file
+			close(result)
+			results <- result
+		}
+
+		for file := range queue {
+			if file.Failed() {
+				if file.Dir {
+					unlisted[file.Root] = append(unlisted[file.Root], file.Path)
+				}
+				// Failures from phase 1 are passed through in order
+				pass(file)
+				continue
+			}
+
+			if options.Cache != nil {
+				seen[file.Path] = true
+				if cached, ok := options.Cache.Lookup(file); ok && !options.Rescan {
+					pass(cached)
+					continue
+				}
+			}
+
 			select {
 			case <-s.tokens:
 			case <-ctx.Done():
@@ -145,45 +309,214 @@ func (s *Scanner) scan(ctx context.Context, done chan<- struct{}, dir string, in
 				return
 			}
 
-			result := make(chan File, 1)
+			result := completed
+			if result == nil {
+				result = make(chan File, 1)
+				results <- result
+			}
 
-			go func(path string, result chan<- File) {
-				defer close(result)
-				//fmt.Printf("Scanning %s\n", path)
-				if version, err := ReadDrawingVersion(path); err == nil {
-					result <- File{Path: path, Version: version}
+			pending.Add(1)
+			go func(file File, result chan<- File) {
+				defer pending.Done()
+				//fmt.Printf("Scanning %s\n", file.Path)
+				read := s.readWithin(file.Path, options.Timeout)
+				if read.Reason == ReasonTimeout && s.abandoned.Load() >= s.maxAbandoned {
+					cancel(errUnresponsive)
+				}
+				read.Root, read.Size, read.ModTime = file.Root, file.Size, file.ModTime
+				if options.Cache != nil {
+					options.Cache.Store(read)
+				}
+				result <- read
+				if result != completed {
+					close(result)
 				}
 				s.tokens <- token{}
-			}(path, result)
-
-			results <- result
+			}(file, result)
 		}
 	}()
 
-	// Phase 3: Collect results in order and append them to the table
+	// Phase 3: Collect results and deliver them to the sink
 	t := time.NewTicker(time.Millisecond * 200)
 	defer t.Stop()
 
-	var drained bool
+	immediate := unbatched(sink)
+
 	var batch []File
+	flush := func() {
+		if len(batch) > 0 {
+			sink.Append(batch...)
+			for _, file := range batch {
+				summary.record(file)
+				counter.record(file)
+				if file.Reason == ReasonTimeout || (options.SlowThreshold > 0 && file.ReadTime >= options.SlowThreshold) {
+					summary.Slow = append(summary.Slow, file)
+				}
+			}
+			batch = nil
+		}
+	}
+
+	collect := func(file File) {
+		batch = append(batch, file)
+		if immediate {
+			flush()
+		}
+	}
+
+	var drained bool
 	for !drained {
 		select {
 		case result, ok := <-results:
 			if !ok {
 				drained = true
-				s.model.Append(batch...)
+				flush()
 				break
 			}
 			if file, ok := <-result; ok {
-				batch = append(batch, file)
+				collect(file)
+			}
+		case file, ok := <-completed:
+			if !ok {
+				drained = true
+				flush()
+				break
 			}
+			collect(file)
 		case <-t.C:
-			s.model.Append(batch...)
-			batch = batch[:0]
+			flush()
+			if options.Progress != nil {
+				options.Progress(counter.snapshot())
+			}
+		}
+	}
+
+	if options.Progress != nil {
+		progress := counter.snapshot()
+		progress.Done = true
+		options.Progress(progress)
+	}
+
+	if ctx.Err() != nil {
+		sink.Error(context.Cause(ctx))
+		return
+	}
+
+	if options.Cache != nil {
+		for _, root := range roots {
+			options.Cache.Prune(root, seen, unlisted[root])
+		}
+	}
+}
+
+// readWithin reads the drawing at path, giving up once timeout has passed if
+// it isn't zero. A read that is given up on carries on in the background
+// until the file system returns, and its result is discarded. Until then it
+// is counted in s.abandoned.
+func (s *Scanner) readWithin(path string, timeout time.Duration) File {
+	start := time.Now()
+	if timeout <= 0 {
+		file := s.read(path)
+		file.ReadTime = time.Since(start)
+		return file
+	}
+
+	// The read and the timer race to settle its state, so that only a read
+	// that is given up on is counted as abandoned
+	const (
+		reading int32 = iota
+		finished
+		abandoned
+	)
+	var state atomic.Int32
+
+	done := make(chan File, 1) // Buffered so that an abandoned read can finish
+	go func() {
+		done <- s.read(path)
+		if !state.CompareAndSwap(reading, finished) {
+			s.abandoned.Add(-1)
+		}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var file File
+	select {
+	case file = <-done:
+	case <-timer.C:
+		s.abandoned.Add(1)
+		if !state.CompareAndSwap(reading, abandoned) {
+			// The read finished just in time
+			s.abandoned.Add(-1)
+			file = <-done
+			break
+		}
+		return File{
+			Path:     path,
+			Reason:   ReasonTimeout,
+			Err:      fmt.Errorf("no response after %v", timeout),
+			ReadTime: time.Since(start),
 		}
 	}
+	file.ReadTime = time.Since(start)
+	return file
 }
 
-func isDrawingFile(name string) bool {
-	return strings.HasSuffix(strings.ToLower(name), ".dwg")
+// sortWalkOrder sorts files into the order in which a scan of roots finds
+// them: by root, and then by path, one element at a time, as a walk visits
+// the entries of each directory in lexical order.
+func sortWalkOrder(roots []string, files []File) {
+	index := make(map[string]int, len(roots))
+	for i, root := range roots {
+		index[root] = i
+	}
+	sep := string(filepath.Separator)
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if ra, rb := index[a.Root], index[b.Root]; ra != rb {
+			return ra < rb
+		}
+		ea, eb := strings.Split(a.Path, sep), strings.Split(b.Path, sep)
+		for k := 0; k < len(ea) && k < len(eb); k++ {
+			if ea[k] != eb[k] {
+				return ea[k] < eb[k]
+			}
+		}
+		return len(ea) < len(eb)
+	})
+}
+
+// dedupeRoots returns roots without those that are repeated or lie within
+// another of the roots, in their original order.
+func dedupeRoots(roots []string) []string {
+	keys := make([]string, len(roots))
+	for i, root := range roots {
+		keys[i] = cacheKey(root)
+		if !strings.HasSuffix(keys[i], string(filepath.Separator)) {
+			keys[i] += string(filepath.Separator)
+		}
+		if runtime.GOOS == "windows" {
+			keys[i] = strings.ToLower(keys[i])
+		}
+	}
+
+	var unique []string
+	for i, root := range roots {
+		covered := false
+		for j := range roots {
+			if i == j || !strings.HasPrefix(keys[i], keys[j]) {
+				continue
+			}
+			// Of two identical roots, only the first is kept
+			if keys[i] != keys[j] || j < i {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			unique = append(unique, root)
+		}
+	}
+	return unique
 }
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mixedTree creates a tree of empty drawings in dir, a few of which are
+// named slow.dwg, and returns the number of drawings.
+func mixedTree(tb testing.TB, dir string) int {
+	const folders, perFolder, slowEvery = 16, 64, 97
+	tree := make(map[string]string)
+	for i := 0; i < folders; i++ {
+		for j := 0; j < perFolder; j++ {
+			name := fmt.Sprintf("%03d.dwg", j)
+			if len(tree)%slowEvery == 0 {
+				name = fmt.Sprintf("%03d-slow.dwg", j)
+			}
+			tree[fmt.Sprintf("project%02d/%s", i, name)] = ""
+		}
+	}
+	writeTree(tb, dir, tree)
+	return len(tree)
+}
+
+// mixedRead simulates reading drawings from a share where most files
+// respond quickly but a few stall.
+func mixedRead(path string) File {
+	if strings.HasSuffix(path, "-slow.dwg") {
+		time.Sleep(40 * time.Millisecond)
+	} else {
+		time.Sleep(time.Millisecond)
+	}
+	return stubRead(path)
+}
+
+// countingSink counts the results it receives.
+type countingSink struct {
+	files int
+}
+
+func (s *countingSink) Start(roots []string)   {}
+func (s *countingSink) Append(files ...File)   { s.files += len(files) }
+func (s *countingSink) Error(err error)        {}
+func (s *countingSink) Finish(summary Summary) {}
+
+// recordingSink keeps the results it receives, in the order it receives
+// them.
+type recordingSink struct {
+	files []File
+}
+
+func (s *recordingSink) Start(roots []string)   {}
+func (s *recordingSink) Append(files ...File)   { s.files = append(s.files, files...) }
+func (s *recordingSink) Error(err error)        {}
+func (s *recordingSink) Finish(summary Summary) {}
+
+// paths returns the paths of files.
+func paths(files []File) []string {
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.Path
+	}
+	return paths
+}
+
+// writeTree creates the files in tree under root, along with any folders
+// they're in. Tree maps slash-separated paths to the files' contents. Paths
+// that end in a slash are created as empty folders.
+func writeTree(tb testing.TB, root string, tree map[string]string) {
+	tb.Helper()
+	for name, data := range tree {
+		if strings.HasSuffix(name, "/") {
+			if err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(name)), 0o755); err != nil {
+				tb.Fatal(err)
+			}
+			continue
+		}
+		name = filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+// stubRead returns an AutoCAD 2018 drawing for path without reading it.
+func stubRead(path string) File {
+	return File{Path: path, Format: FormatDWG, Version: "AC1032"}
+}
+
+// lockedReadDir lists folders like os.ReadDir, except that access to the
+// folders named locked is denied.
+func lockedReadDir(name string) ([]fs.DirEntry, error) {
+	if filepath.Base(name) == "locked" {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return os.ReadDir(name)
+}
+
+// stubScanner returns a scanner with the given number of workers that reads
+// drawings with stubRead and lists folders with lockedReadDir.
+func stubScanner(workers int) *Scanner {
+	scanner := NewScanner(workers)
+	scanner.read = stubRead
+	scanner.readDir = lockedReadDir
+	return scanner
+}
+
+func TestScanUnordered(t *testing.T) {
+	dir := t.TempDir()
+	n := mixedTree(t, dir)
+
+	// The drawings in walk order, as an ordered scan delivers them
+	var want []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			want = append(want, path)
+		}
+		return err
+	})
+
+	tests := []struct {
+		name    string
+		options ScanOptions
+		sorted  bool
+		ordered bool // Whether the results should arrive in walk order
+	}{
+		{name: "Ordered", ordered: true},
+		{name: "Unordered", options: ScanOptions{Unordered: true}},
+		{name: "Sorted", options: ScanOptions{Unordered: true}, sorted: true, ordered: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := stubScanner(DefaultWorkers)
+			scanner.read = mixedRead
+
+			recorder := &recordingSink{}
+			var sink Sink = recorder
+			if tt.sorted {
+				sink = SortedSink(sink)
+			}
+			summary, err := scanAndWait(scanner, []string{dir}, sink, tt.options, nil)
+			if err != nil {
+				t.Fatalf("scan error = %v", err)
+			}
+			if len(recorder.files) != n || summary.Drawings != n {
+				t.Fatalf("got %d results, summary of %d, want %d", len(recorder.files), summary.Drawings, n)
+			}
+
+			got := paths(recorder.files)
+			if tt.ordered {
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("results weren't delivered in walk order")
+				}
+				return
+			}
+
+			// Slow drawings are overtaken by the others, but every drawing
+			// is still delivered once
+			if reflect.DeepEqual(got, want) {
+				t.Errorf("results were delivered in walk order despite slow reads")
+			}
+			sort.Strings(got)
+			sorted := append([]string(nil), want...)
+			sort.Strings(sorted)
+			if !reflect.DeepEqual(got, sorted) {
+				t.Errorf("results aren't the drawings found by the walk")
+			}
+		})
+	}
+}
+
+func BenchmarkScanMixed(b *testing.B) {
+	dir := b.TempDir()
+	n := mixedTree(b, dir)
+
+	for _, bench := range []struct {
+		name    string
+		options ScanOptions
+		sorted  bool
+	}{
+		{name: "ordered"},
+		{name: "unordered", options: ScanOptions{Unordered: true}},
+		{name: "sorted", options: ScanOptions{Unordered: true}, sorted: true},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			scanner := stubScanner(DefaultWorkers)
+			scanner.read = mixedRead
+
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				counter := &countingSink{}
+				var sink Sink = counter
+				if bench.sorted {
+					sink = SortedSink(sink)
+				}
+				if _, err := scanAndWait(scanner, []string{dir}, sink, bench.options, nil); err != nil {
+					b.Fatal(err)
+				}
+				if counter.files != n {
+					b.Fatalf("got %d results, want %d", counter.files, n)
+				}
+			}
+			b.ReportMetric(float64(n*b.N)/time.Since(start).Seconds(), "files/s")
+		})
+	}
+}
+
+func TestDedupeRoots(t *testing.T) {
+	a := filepath.Join("srv", "projects")
+	b := filepath.Join("srv", "archive")
+
+	tests := []struct {
+		name  string
+		roots []string
+		want  []string
+	}{
+		{"Distinct", []string{a, b}, []string{a, b}},
+		{"Repeated", []string{a, b, a}, []string{a, b}},
+		{"Unclean", []string{a + string(filepath.Separator), a}, []string{a + string(filepath.Separator)}},
+		{"Nested", []string{filepath.Join(a, "1042"), b, a}, []string{b, a}},
+		{"Prefix", []string{a, a + "-old"}, []string{a, a + "-old"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dedupeRoots(tt.roots); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dedupeRoots(%q) = %q, want %q", tt.roots, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanRoots(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+	writeTree(t, a, map[string]string{"site.dwg": "", "1042/plan.dwg": ""})
+	writeTree(t, b, map[string]string{"old.dwg": ""})
+
+	scanner := stubScanner(2)
+
+	// The nested root is only scanned as part of the first
+	counter := &countingSink{}
+	summary, err := scanAndWait(scanner, []string{a, b, filepath.Join(a, "1042")}, counter, ScanOptions{}, nil)
+	if err != nil {
+		t.Fatalf("scan error = %v", err)
+	}
+
+	if !reflect.DeepEqual(summary.Roots, []string{a, b}) {
+		t.Errorf("Roots = %q, want %q", summary.Roots, []string{a, b})
+	}
+	if counter.files != 3 || summary.Files != 3 {
+		t.Errorf("got %d results, summary of %d, want 3", counter.files, summary.Files)
+	}
+	var perRoot []int
+	for _, totals := range summary.PerRoot {
+		perRoot = append(perRoot, totals.Drawings)
+	}
+	if !reflect.DeepEqual(perRoot, []int{2, 1}) {
+		t.Errorf("drawings per root = %v, want [2 1]", perRoot)
+	}
+}
+
+// stuckRead returns a read function that doesn't respond for the files named
+// stuck.dwg until release is closed.
+func stuckRead(release <-chan struct{}) func(path string) File {
+	return func(path string) File {
+		if filepath.Base(path) == "stuck.dwg" {
+			<-release
+		}
+		return stubRead(path)
+	}
+}
+
+func TestReadWithin(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		timeout time.Duration
+		reason  Reason
+	}{
+		{"Responsive", "site.dwg", time.Second, ReasonNone},
+		{"NoTimeout", "site.dwg", 0, ReasonNone},
+		{"Stuck", "stuck.dwg", 10 * time.Millisecond, ReasonTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			scanner := stubScanner(1)
+			scanner.read = stuckRead(release)
+
+			file := scanner.readWithin(tt.path, tt.timeout)
+			if file.Reason != tt.reason {
+				t.Errorf("readWithin() reason = %v, want %v", file.Reason, tt.reason)
+			}
+
+			// A read that timed out is counted until it returns
+			want := int32(0)
+			if tt.reason == ReasonTimeout {
+				want = 1
+			}
+			if got := scanner.abandoned.Load(); got != want {
+				t.Errorf("abandoned = %d, want %d", got, want)
+			}
+			close(release)
+			for deadline := time.Now().Add(time.Second); scanner.abandoned.Load() != 0; {
+				if time.Now().After(deadline) {
+					t.Fatalf("abandoned = %d after the read returned, want 0", scanner.abandoned.Load())
+				}
+				time.Sleep(time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestScanTimeout(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"a.dwg": "", "stuck.dwg": "", "z.dwg": ""})
+
+	tests := []struct {
+		name         string
+		maxAbandoned int32
+		err          error
+	}{
+		{"Abandoned", maxAbandonedReads, nil},
+		{"Unresponsive", 1, errUnresponsive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			defer close(release)
+			scanner := stubScanner(2)
+			scanner.read = stuckRead(release)
+			scanner.maxAbandoned = tt.maxAbandoned
+
+			options := ScanOptions{Timeout: 10 * time.Millisecond}
+			summary, err := scanAndWait(scanner, []string{dir}, &countingSink{}, options, nil)
+			if err != tt.err {
+				t.Fatalf("scan error = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+
+			if summary.Failures[ReasonTimeout] != 1 || summary.FreshReads != 3 {
+				t.Errorf("summary = %+v, want 1 timeout in 3 fresh reads", summary.Totals)
+			}
+			if len(summary.Slow) != 1 || filepath.Base(summary.Slow[0].Path) != "stuck.dwg" {
+				t.Errorf("Slow = %+v, want only stuck.dwg", summary.Slow)
+			}
+		})
+	}
+}
+
+func TestScanPruneUnlisted(t *testing.T) {
+	root := t.TempDir()
+	site := filepath.Join(root, "site.dwg")
+	locked := filepath.Join(root, "locked", "plan.dwg")
+	deleted := filepath.Join(root, "deleted.dwg")
+	writeTree(t, root, map[string]string{"site.dwg": "", "locked/plan.dwg": ""})
+
+	cache, err := OpenCache(filepath.Join(t.TempDir(), "cache.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{site, locked, deleted} {
+		cache.Store(File{Path: path})
+	}
+
+	// The locked folder can't be listed, so its drawing isn't found
+	if _, err := scanAndWait(stubScanner(2), []string{root}, &countingSink{}, ScanOptions{Cache: cache}, nil); err != nil {
+		t.Fatalf("scan error = %v", err)
+	}
+
+	for _, tt := range []struct {
+		path string
+		kept bool
+	}{
+		{site, true},
+		{locked, true},
+		{deleted, false},
+	} {
+		if _, ok := cache.entries[cacheKey(tt.path)]; ok != tt.kept {
+			t.Errorf("after scan, cached %s = %v, want %v", tt.path, ok, tt.kept)
+		}
+	}
+}
@@ -1,8 +1,14 @@
+//go:build windows
+
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/lxn/walk"
 
@@ -12,6 +18,8 @@ import (
 // ScanWindow is the main scanning window.
 type ScanWindow struct {
 	scanner         *Scanner
+	cache           *Cache
+	rules           *Rules
 	model           *ScanModel
 	ui              *ui.MainWindow
 	form            *walk.MainWindow
@@ -19,15 +27,24 @@ type ScanWindow struct {
 	table           *walk.TableView
 	splitter        *walk.Splitter
 	selection       *walk.LineEdit
+	filter          *walk.LineEdit
 	cancel          *walk.PushButton
+	rescan          *walk.CheckBox
+	status          *walk.StatusBarItem
 	actionCopy      *walk.Action
+	actionExport    *walk.Action
 	actionSelectAll *walk.Action
+	summary         Summary // The summary of the last scan to finish
+	scanErr         error   // The error that stopped the current scan, if any
 }
 
-// NewScanWindow returns a new scanning window.
-func NewScanWindow(scanner *Scanner, treeModel *DirectoryTreeModel, scanModel *ScanModel) (window *ScanWindow, err error) {
+// NewScanWindow returns a new scanning window. If cache is not nil, scans
+// consult and update it. Scans follow rules.
+func NewScanWindow(scanner *Scanner, cache *Cache, rules *Rules, treeModel *DirectoryTreeModel, scanModel *ScanModel) (window *ScanWindow, err error) {
 	window = &ScanWindow{
 		scanner: scanner,
+		cache:   cache,
+		rules:   rules,
 		model:   scanModel,
 	}
 
@@ -44,6 +61,17 @@ func NewScanWindow(scanner *Scanner, treeModel *DirectoryTreeModel, scanModel *S
 		Layout:   ui.VBox{},
 		AssignTo: &window.form,
 		MenuItems: []ui.MenuItem{
+			ui.Menu{
+				Text: "&File",
+				Items: []ui.MenuItem{
+					ui.Action{
+						AssignTo:    &window.actionExport,
+						Text:        "&Export...",
+						Shortcut:    ui.Shortcut{Key: walk.KeyE, Modifiers: walk.ModControl},
+						OnTriggered: window.onExportResults,
+					},
+				},
+			},
 			ui.Menu{
 				Text: "&Edit",
 				Items: []ui.MenuItem{
@@ -76,11 +104,7 @@ func NewScanWindow(scanner *Scanner, treeModel *DirectoryTreeModel, scanModel *S
 						Name:           "table",
 						AssignTo:       &window.table,
 						MultiSelection: true,
-						Columns: []ui.TableViewColumn{
-							{Title: "File", Width: 300},
-							{Title: "Header", Width: 200},
-							{Title: "Version", Width: 200},
-						},
+						Columns:        tableColumns(),
 						ContextMenuItems: []ui.MenuItem{
 							ui.ActionRef{Action: &window.actionSelectAll},
 							ui.ActionRef{Action: &window.actionCopy},
@@ -95,6 +119,16 @@ func NewScanWindow(scanner *Scanner, treeModel *DirectoryTreeModel, scanModel *S
 					ui.LineEdit{AssignTo: &window.selection},
 				},
 			},
+			ui.HSplitter{
+				Children: []ui.Widget{
+					ui.Label{Text: "Filter:"},
+					ui.LineEdit{
+						AssignTo:          &window.filter,
+						ToolTipText:       "An expression such as: release < 13 && size > 50MB",
+						OnEditingFinished: window.onFilter,
+					},
+				},
+			},
 			ui.HSplitter{
 				Children: []ui.Widget{
 					ui.PushButton{
@@ -107,9 +141,17 @@ func NewScanWindow(scanner *Scanner, treeModel *DirectoryTreeModel, scanModel *S
 						OnClicked: window.onCancel,
 						Enabled:   false,
 					},
+					ui.CheckBox{
+						AssignTo: &window.rescan,
+						Text:     "Full rescan",
+						Visible:  cache != nil,
+					},
 				},
 			},
 		},
+		StatusBarItems: []ui.StatusBarItem{
+			{AssignTo: &window.status, Width: 600},
+		},
 	}
 
 	err = window.ui.Create()
@@ -136,21 +178,111 @@ func (window *ScanWindow) onSelection() {
 	window.selection.SetText(dir.Path())
 }
 
+// onScan scans the directories in the selection box, which may hold several
+// separated by semicolons.
 func (window *ScanWindow) onScan() {
-	root := window.selection.Text()
-	go window.scanner.Scan(
-		root,
-		func() { window.form.Synchronize(window.onScanStarted) },
-		func() { window.form.Synchronize(window.onScanCompleted) },
-	)
+	var roots []string
+	for _, root := range strings.Split(window.selection.Text(), ";") {
+		if root = strings.TrimSpace(root); root != "" {
+			roots = append(roots, root)
+		}
+	}
+	if len(roots) == 0 {
+		return
+	}
+
+	options := ScanOptions{
+		Cache:    window.cache,
+		Rescan:   window.rescan.Checked(),
+		Rules:    window.rules,
+		Progress: window.onScanProgress,
+
+		Timeout:       DefaultReadTimeout,
+		SlowThreshold: DefaultSlowThreshold,
+
+		// The model restores the order of the results when the scan ends
+		Unordered: true,
+	}
+	go window.scanner.Scan(roots, MultiSink(window.model, windowSink{window}), options)
 }
 
 func (window *ScanWindow) onScanStarted() {
+	window.summary, window.scanErr = Summary{}, nil
 	window.cancel.SetEnabled(true)
+	window.status.SetText("Scanning...")
 }
 
-func (window *ScanWindow) onScanCompleted() {
+// onScanProgress is called from the scanning goroutine, so it relays the
+// progress to the user interface thread.
+func (window *ScanWindow) onScanProgress(progress Progress) {
+	if progress.Done {
+		return // The summary is shown instead
+	}
+	window.form.Synchronize(func() {
+		window.status.SetText("Scanning: " + progress.String() + ".")
+	})
+}
+
+func (window *ScanWindow) onScanCompleted(summary Summary) {
+	window.summary = summary
 	window.cancel.SetEnabled(false)
+
+	scanned := "Scanned"
+	switch {
+	case errors.Is(window.scanErr, context.Canceled):
+		scanned = "Cancelled after scanning"
+	case window.scanErr != nil:
+		scanned = fmt.Sprintf("Failed (%v) after scanning", window.scanErr)
+	}
+
+	text := fmt.Sprintf("%s %s: %s.", scanned, joinRoots(summary.Roots), summary.Totals)
+	var details []string
+	if len(summary.Roots) > 1 {
+		// The totals of each root are too long for the status bar
+		text = fmt.Sprintf("%s %d roots: %s.", scanned, len(summary.Roots), summary.Totals)
+		for i, root := range summary.Roots {
+			details = append(details, fmt.Sprintf("%s: %s.", root, summary.PerRoot[i]))
+		}
+	}
+	if !summary.Complete() || window.scanErr != nil {
+		text += " Coverage is incomplete."
+	}
+	if len(summary.Slow) > 0 {
+		text += fmt.Sprintf(" %d drawings were slow to read.", len(summary.Slow))
+		details = append(details, "Slow to read:")
+		for i, file := range summary.Slow {
+			if i == 10 {
+				details = append(details, fmt.Sprintf("and %d more", len(summary.Slow)-i))
+				break
+			}
+			details = append(details, fmt.Sprintf("%s: %v", file.Path, file.ReadTime.Round(time.Millisecond)))
+		}
+	}
+	window.status.SetText(text)
+	window.status.SetToolTipText(strings.Join(details, "\n"))
+
+	if window.cache != nil {
+		go window.cache.Save()
+	}
+}
+
+func (window *ScanWindow) onFilter() {
+	text := strings.TrimSpace(window.filter.Text())
+	if text == "" {
+		window.model.SetFilter(nil)
+		window.status.SetText("Showing all results.")
+		return
+	}
+
+	filter, err := ParseFilter(text)
+	if err != nil {
+		window.status.SetText(fmt.Sprintf("Invalid filter: %v", err))
+		return
+	}
+
+	window.model.SetFilter(filter)
+	shown, total := window.model.Counts()
+	window.status.SetText(fmt.Sprintf("Showing %d of %d results.", shown, total))
 }
 
 func (window *ScanWindow) onCancel() {
@@ -194,10 +326,81 @@ func (window *ScanWindow) onCopyResults() {
 
 	var lines []string
 	for _, file := range files {
-		lines = append(lines, fmt.Sprintf("%-*s  %-*s  %s", maxPath, file.Path, maxHeader, file.Version.String(), file.Version.ReleaseName()))
+		line := fmt.Sprintf("%-*s  %-*s  %s", maxPath, file.Path, maxHeader, file.Version.String(), file.Version.ReleaseName())
+		if file.Failed() {
+			line = strings.TrimRight(line, " ") + "  " + file.Reason.String()
+		}
+		lines = append(lines, line)
 	}
 
 	text := strings.Join(lines, "\n")
 
 	walk.Clipboard().SetText(text)
 }
+
+func (window *ScanWindow) onExportResults() {
+	dialog := walk.FileDialog{
+		Title:    "Export Results",
+		Filter:   "CSV Files (*.csv)|*.csv|HTML Reports (*.html)|*.html|Excel Workbooks (*.xlsx)|*.xlsx|All Files (*.*)|*.*",
+		FilePath: "cadscan.csv",
+	}
+
+	accepted, err := dialog.ShowSave(window.form)
+	if err != nil || !accepted {
+		return
+	}
+
+	path := dialog.FilePath
+	if filepath.Ext(path) == "" {
+		switch dialog.FilterIndex {
+		case 2:
+			path += ".html"
+		case 3:
+			path += ".xlsx"
+		default:
+			path += ".csv"
+		}
+	}
+
+	format := "csv"
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		format = "html"
+	case ".xlsx":
+		format = "xlsx"
+	}
+
+	if err := exportResults(path, format, window.summary, window.model.Results()); err != nil {
+		walk.MsgBox(window.form, "Export Failed", fmt.Sprintf("Unable to export the results to %s: %v", path, err), walk.MsgBoxIconError)
+	}
+}
+
+// tableColumns returns the result table's columns.
+func tableColumns() []ui.TableViewColumn {
+	columns := make([]ui.TableViewColumn, len(resultColumns))
+	for i, c := range resultColumns {
+		columns[i] = ui.TableViewColumn{Title: c.Title, Width: c.Width}
+	}
+	return columns
+}
+
+// windowSink relays scan events to the window's user interface thread.
+type windowSink struct {
+	window *ScanWindow
+}
+
+func (s windowSink) Start(roots []string) {
+	s.window.form.Synchronize(s.window.onScanStarted)
+}
+
+func (s windowSink) Append(files ...File) {}
+
+// Error records the error that stopped the scan, which is reported once it
+// has finished.
+func (s windowSink) Error(err error) {
+	s.window.form.Synchronize(func() { s.window.scanErr = err })
+}
+
+func (s windowSink) Finish(summary Summary) {
+	s.window.form.Synchronize(func() { s.window.onScanCompleted(summary) })
+}
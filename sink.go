@@ -0,0 +1,120 @@
+package main
+
+// A Sink receives the results of a scan.
+//
+// A Scanner calls Start once, followed by any number of calls to Append and
+// Error, followed by a single call to Finish. All calls for a scan are made
+// from the same goroutine.
+type Sink interface {
+	// Start is called when a scan of the given roots begins.
+	Start(roots []string)
+
+	// Append is called with a batch of results. The sink must not retain
+	// the slice. Results are only delivered in the order the files were
+	// found if the scan is ordered; those of a scan with
+	// ScanOptions.Unordered arrive as they complete, and SortedSink can
+	// put them back in order.
+	Append(files ...File)
+
+	// Error is called when the scan encounters an error that prevents it
+	// from completing, including cancellation.
+	Error(err error)
+
+	// Finish is called when the scan has ended.
+	Finish(summary Summary)
+}
+
+// An UnbatchedSink is a Sink that wants each result as soon as the scanner
+// collects it. Scans recorded to an UnbatchedSink deliver every result in a
+// batch of its own rather than gathering them periodically.
+type UnbatchedSink interface {
+	Sink
+
+	// Unbatched reports whether the sink wants results delivered one at a
+	// time.
+	Unbatched() bool
+}
+
+// unbatched reports whether sink wants results delivered one at a time.
+func unbatched(sink Sink) bool {
+	u, ok := sink.(UnbatchedSink)
+	return ok && u.Unbatched()
+}
+
+// MultiSink returns a sink that relays each call to all of the given sinks,
+// in order.
+func MultiSink(sinks ...Sink) Sink {
+	return multiSink(sinks)
+}
+
+type multiSink []Sink
+
+func (m multiSink) Start(roots []string) {
+	for _, sink := range m {
+		sink.Start(roots)
+	}
+}
+
+func (m multiSink) Append(files ...File) {
+	for _, sink := range m {
+		sink.Append(files...)
+	}
+}
+
+func (m multiSink) Error(err error) {
+	for _, sink := range m {
+		sink.Error(err)
+	}
+}
+
+func (m multiSink) Finish(summary Summary) {
+	for _, sink := range m {
+		sink.Finish(summary)
+	}
+}
+
+// Unbatched reports whether any of the sinks want results delivered one at a
+// time.
+func (m multiSink) Unbatched() bool {
+	for _, sink := range m {
+		if unbatched(sink) {
+			return true
+		}
+	}
+	return false
+}
+
+// SortedSink returns a sink that holds the results of each scan until it
+// finishes and then relays them to sink in the order the scan found them.
+// It restores the order of the results of an unordered scan.
+func SortedSink(sink Sink) Sink {
+	return &sortedSink{sink: sink}
+}
+
+type sortedSink struct {
+	sink  Sink
+	roots []string
+	files []File
+}
+
+func (s *sortedSink) Start(roots []string) {
+	s.roots = roots
+	s.sink.Start(roots)
+}
+
+func (s *sortedSink) Append(files ...File) {
+	s.files = append(s.files, files...)
+}
+
+func (s *sortedSink) Error(err error) {
+	s.sink.Error(err)
+}
+
+func (s *sortedSink) Finish(summary Summary) {
+	sortWalkOrder(s.roots, s.files)
+	if len(s.files) > 0 {
+		s.sink.Append(s.files...)
+	}
+	s.files = nil
+	s.sink.Finish(summary)
+}
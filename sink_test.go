@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSortedSink(t *testing.T) {
+	// The second root sorts first, but its results must come last
+	a, b := filepath.Join("srv", "projects"), filepath.Join("srv", "archive")
+	p := filepath.Join
+
+	// In walk order, where a folder's contents come before the names that
+	// sort after it as strings, such as "1042-old" or "1042.dwg"
+	want := []File{
+		{Path: p(a, "1042", "site.dwg"), Root: a},
+		{Path: p(a, "1042", "sub", "plan.dwg"), Root: a},
+		{Path: p(a, "1042-old", "site.dwg"), Root: a},
+		{Path: p(a, "1042.dwg"), Root: a},
+		{Path: p(b, "old.dwg"), Root: b},
+	}
+
+	recorder := &recordingSink{}
+	sink := SortedSink(recorder)
+	sink.Start([]string{a, b})
+	sink.Append(want[4], want[2])
+	sink.Append(want[3])
+	sink.Append(want[1], want[0])
+	if len(recorder.files) != 0 {
+		t.Fatalf("relayed %d results before the scan finished", len(recorder.files))
+	}
+	sink.Finish(Summary{})
+
+	if got := paths(recorder.files); !reflect.DeepEqual(got, paths(want)) {
+		t.Errorf("relayed %q, want %q", got, paths(want))
+	}
+}
+
+// callSink records the calls made to it in a shared log.
+type callSink struct {
+	name      string
+	log       *[]string
+	unbatched bool
+}
+
+func (s *callSink) Start(roots []string) {
+	*s.log = append(*s.log, fmt.Sprintf("%s: Start %s", s.name, strings.Join(roots, ",")))
+}
+
+func (s *callSink) Append(files ...File) {
+	*s.log = append(*s.log, fmt.Sprintf("%s: Append %s", s.name, strings.Join(paths(files), ",")))
+}
+
+func (s *callSink) Error(err error) {
+	*s.log = append(*s.log, fmt.Sprintf("%s: Error %v", s.name, err))
+}
+
+func (s *callSink) Finish(summary Summary) {
+	*s.log = append(*s.log, fmt.Sprintf("%s: Finish %d", s.name, summary.Files))
+}
+
+func (s *callSink) Unbatched() bool {
+	return s.unbatched
+}
+
+func TestMultiSink(t *testing.T) {
+	var log []string
+	a, b := &callSink{name: "a", log: &log}, &callSink{name: "b", log: &log}
+	sink := MultiSink(a, b)
+
+	sink.Start([]string{"projects"})
+	sink.Append(File{Path: "site.dwg"}, File{Path: "plan.dwg"})
+	sink.Error(errors.New("stopped"))
+	sink.Finish(Summary{Totals: Totals{Files: 2}})
+
+	want := []string{
+		"a: Start projects",
+		"b: Start projects",
+		"a: Append site.dwg,plan.dwg",
+		"b: Append site.dwg,plan.dwg",
+		"a: Error stopped",
+		"b: Error stopped",
+		"a: Finish 2",
+		"b: Finish 2",
+	}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("calls:\n%s\nwant:\n%s", strings.Join(log, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestMultiSinkUnbatched(t *testing.T) {
+	var log []string
+	declined := &callSink{log: &log, unbatched: false}
+	streaming := &callSink{log: &log, unbatched: true}
+
+	tests := []struct {
+		name  string
+		sinks []Sink
+		want  bool
+	}{
+		{"None", nil, false},
+		{"Batched", []Sink{&recordingSink{}}, false},
+		{"Declined", []Sink{declined}, false},
+		{"Streaming", []Sink{&recordingSink{}, streaming}, true},
+		{"Nested", []Sink{MultiSink(&recordingSink{}, MultiSink(streaming))}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unbatched(MultiSink(tt.sinks...)); got != tt.want {
+				t.Errorf("unbatched() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Snapshots are the JSON documents written by the scan command's -snapshot
+// flag or its json output format. They record the results of a scan so that
+// they can be compared with a later one. Documents written with a filter
+// leave files out, so they aren't snapshots.
+
+// loadSnapshot reads the snapshot stored in the file with the given name.
+func loadSnapshot(name string) (*jsonDocument, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc jsonDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s is not a snapshot: %v", name, err)
+	}
+	if doc.Roots == nil || doc.Files == nil {
+		return nil, fmt.Errorf("%s is not a snapshot: it has no roots or files", name)
+	}
+	if doc.Filter != "" {
+		// Files that the filter left out would appear to have been removed
+		return nil, fmt.Errorf("%s only holds the results that satisfy the filter %q, so it can't be compared", name, doc.Filter)
+	}
+	if len(doc.Errors) > 0 {
+		// Files that weren't reached would appear to have been removed
+		return nil, fmt.Errorf("%s is a snapshot of a scan that didn't finish (%s), so it can't be compared", name, strings.Join(doc.Errors, "; "))
+	}
+
+	return &doc, nil
+}
+
+// Kinds of difference between snapshots.
+const (
+	diffAdded = iota
+	diffRemoved
+	diffUpgraded
+	diffDowngraded
+	diffKinds
+)
+
+// diffKindNames are the names of each kind of difference, in order.
+var diffKindNames = [diffKinds]string{"added", "removed", "upgraded", "downgraded"}
+
+// fileChange is a difference in a file between two snapshots. Before is
+// empty for added files and After is empty for removed files.
+type fileChange struct {
+	Kind   int
+	Path   string
+	Before jsonFile
+	After  jsonFile
+}
+
+// snapshotDiff lists the differences between two snapshots.
+type snapshotDiff struct {
+	Changes     []fileChange               // Ordered by path
+	Directories map[string]*[diffKinds]int // Number of each kind of change by directory
+	Unreached   int                        // Number of files in directories that one of the scans couldn't read
+}
+
+// diffSnapshots compares the files in snapshot before with those in the
+// later snapshot after.
+//
+// A file's version is considered to have changed only if it could be read
+// in both snapshots. Versions are compared by release, so a change to a
+// later release is an upgrade and a change to an earlier one a downgrade.
+//
+// Directories that couldn't be read are not files, so they aren't compared.
+// Files within a directory that one of the scans couldn't read aren't
+// reported as added or removed, because that scan didn't reach them.
+func diffSnapshots(before, after *jsonDocument) snapshotDiff {
+	oldFiles, oldDirs := snapshotFiles(before)
+	newFiles, newDirs := snapshotFiles(after)
+
+	diff := snapshotDiff{Directories: make(map[string]*[diffKinds]int)}
+	add := func(change fileChange) {
+		diff.Changes = append(diff.Changes, change)
+		dir := filepath.Dir(change.Path)
+		counts := diff.Directories[dir]
+		if counts == nil {
+			counts = new([diffKinds]int)
+			diff.Directories[dir] = counts
+		}
+		counts[change.Kind]++
+	}
+
+	for path, n := range newFiles {
+		o, ok := oldFiles[path]
+		switch {
+		case !ok && withinAny(oldDirs, path):
+			diff.Unreached++
+		case !ok:
+			add(fileChange{Kind: diffAdded, Path: path, After: n})
+		case o.Release == 0 || n.Release == 0 || o.Release == n.Release:
+		case n.Release > o.Release:
+			add(fileChange{Kind: diffUpgraded, Path: path, Before: o, After: n})
+		default:
+			add(fileChange{Kind: diffDowngraded, Path: path, Before: o, After: n})
+		}
+	}
+	for path, o := range oldFiles {
+		if _, ok := newFiles[path]; ok {
+			continue
+		}
+		if withinAny(newDirs, path) {
+			diff.Unreached++
+			continue
+		}
+		add(fileChange{Kind: diffRemoved, Path: path, Before: o})
+	}
+
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		return diff.Changes[i].Path < diff.Changes[j].Path
+	})
+
+	return diff
+}
+
+// snapshotFiles returns the files recorded in doc by path, and the paths of
+// the directories that its scan couldn't read.
+func snapshotFiles(doc *jsonDocument) (files map[string]jsonFile, dirs []string) {
+	files = make(map[string]jsonFile, len(doc.Files))
+	for _, file := range doc.Files {
+		if file.Dir {
+			dirs = append(dirs, file.Path)
+			continue
+		}
+		files[file.Path] = file
+	}
+	return files, dirs
+}
+
+// withinAny reports whether path lies within any of dirs.
+func withinAny(dirs []string, path string) bool {
+	for _, dir := range dirs {
+		if strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
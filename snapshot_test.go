@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	p := func(elem ...string) string {
+		return filepath.Join(append([]string{"projects"}, elem...)...)
+	}
+	drawing := func(path string, release int) jsonFile {
+		return jsonFile{Path: path, Release: release}
+	}
+	dir := func(path string) jsonFile {
+		return jsonFile{Path: path, Dir: true, Reason: "permission denied"}
+	}
+
+	tests := []struct {
+		name      string
+		before    []jsonFile
+		after     []jsonFile
+		want      map[string]int // Kind of change by path
+		unreached int
+	}{
+		{
+			name:   "Unchanged",
+			before: []jsonFile{drawing(p("a.dwg"), 14)},
+			after:  []jsonFile{drawing(p("a.dwg"), 14)},
+			want:   map[string]int{},
+		},
+		{
+			name:   "AddedAndRemoved",
+			before: []jsonFile{drawing(p("a.dwg"), 14), drawing(p("b.dwg"), 14)},
+			after:  []jsonFile{drawing(p("b.dwg"), 14), drawing(p("c.dwg"), 18)},
+			want:   map[string]int{p("a.dwg"): diffRemoved, p("c.dwg"): diffAdded},
+		},
+		{
+			name:   "UpgradedAndDowngraded",
+			before: []jsonFile{drawing(p("a.dwg"), 14), drawing(p("b.dwg"), 18)},
+			after:  []jsonFile{drawing(p("a.dwg"), 17), drawing(p("b.dwg"), 13)},
+			want:   map[string]int{p("a.dwg"): diffUpgraded, p("b.dwg"): diffDowngraded},
+		},
+		{
+			name:   "Unreadable",
+			before: []jsonFile{drawing(p("a.dwg"), 14)},
+			after:  []jsonFile{{Path: p("a.dwg"), Reason: "truncated header"}},
+			want:   map[string]int{},
+		},
+		{
+			name:      "UnreadableDirectoryAfter",
+			before:    []jsonFile{drawing(p("a.dwg"), 14), drawing(p("1042", "b.dwg"), 14), drawing(p("1042-old", "c.dwg"), 14)},
+			after:     []jsonFile{drawing(p("a.dwg"), 14), dir(p("1042"))},
+			want:      map[string]int{p("1042-old", "c.dwg"): diffRemoved},
+			unreached: 1,
+		},
+		{
+			name:      "UnreadableDirectoryBefore",
+			before:    []jsonFile{dir(p("1042"))},
+			after:     []jsonFile{drawing(p("1042", "b.dwg"), 14), drawing(p("c.dwg"), 14)},
+			want:      map[string]int{p("c.dwg"): diffAdded},
+			unreached: 1,
+		},
+		{
+			name:   "DirectoryRecovered",
+			before: []jsonFile{dir(p("1042"))},
+			after:  []jsonFile{},
+			want:   map[string]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := diffSnapshots(&jsonDocument{Files: tt.before}, &jsonDocument{Files: tt.after})
+
+			got := make(map[string]int)
+			for _, change := range diff.Changes {
+				got[change.Path] = change.Kind
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffSnapshots() changes = %v, want %v", got, tt.want)
+			}
+			if diff.Unreached != tt.unreached {
+				t.Errorf("diffSnapshots() unreached = %d, want %d", diff.Unreached, tt.unreached)
+			}
+		})
+	}
+}
+
+func TestLoadSnapshot(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  interface{}
+		err  string
+	}{
+		{"Complete", jsonDocument{Roots: []string{"projects"}, Files: []jsonFile{}, Complete: true}, ""},
+		{"Incomplete", jsonDocument{Roots: []string{"projects"}, Files: []jsonFile{}}, ""},
+		{"Interrupted", jsonDocument{Roots: []string{"projects"}, Files: []jsonFile{}, Errors: []string{"context canceled"}}, "didn't finish"},
+		{"Filtered", jsonDocument{Roots: []string{"projects"}, Files: []jsonFile{}, Filter: "failed"}, "satisfy the filter"},
+		{"NotSnapshot", map[string]int{"files": 1}, "not a snapshot"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			name := filepath.Join(t.TempDir(), "snapshot.json")
+			if err := os.WriteFile(name, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = loadSnapshot(name)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("loadSnapshot() error = %v", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("loadSnapshot() error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Summary describes the outcome of a scan of one or more roots.
+type Summary struct {
+	Roots    []string
+	Workers  int
+	Started  time.Time
+	Finished time.Time
+
+	Totals           // Totals of every root
+	PerRoot []Totals // Totals of each root, in the same order as Roots
+	Slow    []File   // Drawings that were slow to read or timed out, slowest first
+}
+
+// Totals counts the results of a scan, or of one of its roots.
+type Totals struct {
+	Files    int            // Number of results reported, including failures
+	Drawings int            // Number of drawings read successfully
+	Failures map[Reason]int // Number of files and directories that could not be assessed
+
+	CacheHits  int // Number of results taken from the scan cache
+	FreshReads int // Number of files read, including those that timed out but not those that couldn't be opened
+}
+
+// newSummary returns an empty summary of a scan of roots.
+func newSummary(roots []string, workers int) Summary {
+	return Summary{
+		Roots:   roots,
+		Workers: workers,
+		Started: time.Now(),
+		PerRoot: make([]Totals, len(roots)),
+	}
+}
+
+// Duration returns the length of time the scan ran for.
+func (s Summary) Duration() time.Duration {
+	return s.Finished.Sub(s.Started)
+}
+
+// record adds file to the summary's totals and those of its root.
+func (s *Summary) record(file File) {
+	s.Totals.record(file)
+	for i, root := range s.Roots {
+		if root == file.Root {
+			s.PerRoot[i].record(file)
+			break
+		}
+	}
+}
+
+// Failed returns the number of files and directories that could not be
+// assessed.
+func (t Totals) Failed() int {
+	total := 0
+	for _, count := range t.Failures {
+		total += count
+	}
+	return total
+}
+
+// Complete reports whether every file and directory that was found could be
+// assessed. If not, the scan's coverage is incomplete.
+func (t Totals) Complete() bool {
+	return t.Failed() == 0
+}
+
+// String returns a one-line description of the totals.
+func (t Totals) String() string {
+	text := fmt.Sprintf("%d drawings read", t.Drawings)
+	if t.CacheHits > 0 {
+		text += fmt.Sprintf(" (%d from cache, %d read fresh)", t.CacheHits, t.FreshReads)
+	}
+	if t.Complete() {
+		return text
+	}
+
+	var reasons []string
+	for r := Reason(1); int(r) < reasonCount; r++ {
+		if count := t.Failures[r]; count > 0 {
+			reasons = append(reasons, fmt.Sprintf("%d %s", count, r))
+		}
+	}
+
+	return fmt.Sprintf("%s, %d could not be read (%s)", text, t.Failed(), strings.Join(reasons, ", "))
+}
+
+// record adds file to the totals.
+func (t *Totals) record(file File) {
+	t.Files++
+	switch {
+	case file.Cached:
+		t.CacheHits++
+	case file.Reason == ReasonTimeout:
+		// Drawings that timed out were opened, but have no format
+		t.FreshReads++
+	case file.Reason == ReasonOpen || file.Reason == ReasonPermission:
+		// Nothing was read from files that couldn't be opened
+	case file.Format != FormatUnknown:
+		// Failures found while walking the file system have no format
+		t.FreshReads++
+	}
+	if !file.Failed() {
+		t.Drawings++
+		return
+	}
+	if t.Failures == nil {
+		t.Failures = make(map[Reason]int)
+	}
+	t.Failures[file.Reason]++
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSummaryRecord(t *testing.T) {
+	a, b := filepath.Join("srv", "projects"), filepath.Join("srv", "archive")
+	summary := newSummary([]string{a, b}, 1)
+
+	for _, file := range []File{
+		{Path: filepath.Join(a, "site.dwg"), Root: a, Format: FormatDWG, Version: "AC1032"},
+		{Path: filepath.Join(a, "plan.dwg"), Root: a, Format: FormatDWG, Version: "AC1015", Cached: true},
+		{Path: filepath.Join(a, "bad.dwg"), Root: a, Format: FormatDWG, Reason: ReasonTruncated, Err: errors.New("truncated")},
+		{Path: filepath.Join(b, "1042"), Root: b, Dir: true, Reason: ReasonPermission, Err: errors.New("access denied")},
+		{Path: filepath.Join(b, "old.dxf"), Root: b, Format: FormatDXF, Version: "AC1009"},
+		{Path: filepath.Join(b, "stuck.dwg"), Root: b, Reason: ReasonTimeout, Err: errors.New("no response")},
+		{Path: filepath.Join(b, "locked.dwg"), Root: b, Format: FormatDWG, Reason: ReasonPermission, Err: errors.New("access denied")},
+	} {
+		summary.record(file)
+	}
+
+	tests := []struct {
+		name   string
+		totals Totals
+		want   Totals
+	}{
+		{"All", summary.Totals, Totals{
+			Files:      7,
+			Drawings:   3,
+			Failures:   map[Reason]int{ReasonTruncated: 1, ReasonPermission: 2, ReasonTimeout: 1},
+			CacheHits:  1,
+			FreshReads: 4,
+		}},
+		{"Projects", summary.PerRoot[0], Totals{
+			Files:      3,
+			Drawings:   2,
+			Failures:   map[Reason]int{ReasonTruncated: 1},
+			CacheHits:  1,
+			FreshReads: 2,
+		}},
+		{"Archive", summary.PerRoot[1], Totals{
+			Files:      4,
+			Drawings:   1,
+			Failures:   map[Reason]int{ReasonPermission: 2, ReasonTimeout: 1},
+			FreshReads: 2, // Timeouts were read, but directories and locked files weren't
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.totals, tt.want) {
+				t.Errorf("totals = %+v, want %+v", tt.totals, tt.want)
+			}
+		})
+	}
+}
+
+func TestTotalsString(t *testing.T) {
+	tests := []struct {
+		name     string
+		totals   Totals
+		want     string
+		complete bool
+	}{
+		{"Empty", Totals{}, "0 drawings read", true},
+		{"Fresh", Totals{Files: 3, Drawings: 3, FreshReads: 3}, "3 drawings read", true},
+		{"Cached", Totals{Files: 3, Drawings: 3, CacheHits: 2, FreshReads: 1}, "3 drawings read (2 from cache, 1 read fresh)", true},
+		{
+			"Failures",
+			Totals{Files: 5, Drawings: 2, Failures: map[Reason]int{ReasonPermission: 1, ReasonTruncated: 2}},
+			"2 drawings read, 3 could not be read (1 permission denied, 2 truncated header)",
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.totals.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			if got := tt.totals.Complete(); got != tt.complete {
+				t.Errorf("Complete() = %v, want %v", got, tt.complete)
+			}
+		})
+	}
+}
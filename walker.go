@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultWalkers is the number of directories a scan lists concurrently
+// unless told otherwise.
+const DefaultWalkers = 8
+
+// walkAhead is the most directory listings that a treeWalker holds ahead of
+// its walk, which bounds the memory used by listings that haven't been
+// walked yet. Once it is reached, workers only read ahead as the walk
+// catches up, and the walk lists directories it reaches first itself.
+const walkAhead = 4096
+
+// treeWalker walks directory trees in the same order as filepath.WalkDir,
+// but lists up to workers directories at a time ahead of the walk. This
+// hides the latency of network file systems, where listing directories one
+// at a time is slower than reading the drawings in them.
+//
+// Directories are listed with os.ReadDir, so files are only stat'd when the
+// walk asks for their info or wantFile selects them.
+type treeWalker struct {
+	workers int // Number of directories to list ahead of the walk at a time, or 0 for none
+
+	// skipDir, if not nil, reports whether the walk skips the directory at
+	// path. Skipped directories aren't listed or passed to the walk
+	// function.
+	skipDir func(path string) bool
+
+	// wantFile, if not nil, reports whether the file at path is passed to
+	// the walk function. The info of the files it selects is read when
+	// their directory is listed, which may be ahead of the walk.
+	//
+	// Both are called once for each entry, possibly from several goroutines
+	// at a time and out of order, but never for the root of the walk.
+	wantFile func(path string) bool
+
+	// readDir lists a directory. If it is nil, os.ReadDir is used.
+	readDir func(name string) ([]fs.DirEntry, error)
+}
+
+// listing holds the contents of a directory. It is read once, by either a
+// worker or the walk itself, whichever reaches it first.
+type listing struct {
+	path     string
+	once     sync.Once
+	entries  []fs.DirEntry // The entries to walk, without those that are skipped
+	err      error
+	children map[string]*listing // Listings of the subdirectories in entries
+	ahead    bool                // Whether a worker read the listing ahead of the walk
+}
+
+// walkState is shared by the walk of a tree and its workers.
+type walkState struct {
+	walker *treeWalker
+	ctx    context.Context
+	ahead  chan struct{} // Holds a token for each listing read ahead and not yet walked
+	done   chan struct{} // Closed when the walk ends
+
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	pending []*listing // Listings for workers to read, the next one last
+	closed  bool
+}
+
+// infoEntry is a directory entry whose info was read ahead of the walk.
+type infoEntry struct {
+	fs.DirEntry
+	info fs.FileInfo
+	err  error
+}
+
+func (e infoEntry) Info() (fs.FileInfo, error) {
+	return e.info, e.err
+}
+
+// walk walks the tree at root, calling fn for each file and directory in
+// lexical order, as filepath.WalkDir does. Returning filepath.SkipDir or
+// filepath.SkipAll from fn has the same effect as it does there. The walk
+// stops when ctx is cancelled, but fn should also check ctx if it needs to
+// stop promptly.
+func (w *treeWalker) walk(ctx context.Context, root string, fn fs.WalkDirFunc) error {
+	info, err := os.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		s := &walkState{
+			walker: w,
+			ctx:    ctx,
+			ahead:  make(chan struct{}, walkAhead),
+			done:   make(chan struct{}),
+		}
+		s.cond = sync.NewCond(&s.mutex)
+		defer s.stop()
+
+		for i := 0; i < w.workers; i++ {
+			go s.work()
+		}
+
+		err = s.walkDir(&listing{path: root}, fs.FileInfoToDirEntry(info), fn)
+	}
+
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walkDir calls fn for the directory d, whose listing is l, and then walks
+// its contents. The root of a walk may also be a file.
+func (s *walkState) walkDir(l *listing, d fs.DirEntry, fn fs.WalkDirFunc) error {
+	if err := fn(l.path, d, nil); err != nil || !d.IsDir() {
+		if err == filepath.SkipDir && d.IsDir() {
+			// Successfully skipped directory
+			err = nil
+		}
+		return err
+	}
+
+	// Read the listing now unless a worker already has or is doing so
+	l.once.Do(func() { s.list(l) })
+	if l.ahead {
+		defer func() { <-s.ahead }()
+	}
+
+	if l.err != nil {
+		if err := fn(l.path, d, l.err); err != nil {
+			if err == filepath.SkipDir {
+				err = nil
+			}
+			return err
+		}
+	}
+
+	for _, entry := range l.entries {
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+
+		var err error
+		if entry.IsDir() {
+			err = s.walkDir(l.children[entry.Name()], entry, fn)
+		} else {
+			err = fn(filepath.Join(l.path, entry.Name()), entry, nil)
+		}
+		if err != nil {
+			if err == filepath.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// list reads the contents of the directory of l, leaving out the entries
+// that the walk skips, and queues the listings of its subdirectories for the
+// workers.
+func (s *walkState) list(l *listing) {
+	readDir := s.walker.readDir
+	if readDir == nil {
+		readDir = os.ReadDir
+	}
+
+	// Entries read before an error are still walked
+	var entries []fs.DirEntry
+	entries, l.err = readDir(l.path)
+
+	var children []*listing
+	for _, entry := range entries {
+		path := filepath.Join(l.path, entry.Name())
+		switch {
+		case entry.IsDir():
+			if s.walker.skipDir != nil && s.walker.skipDir(path) {
+				continue
+			}
+			child := &listing{path: path}
+			if l.children == nil {
+				l.children = make(map[string]*listing)
+			}
+			l.children[entry.Name()] = child
+			children = append(children, child)
+		case s.walker.wantFile == nil:
+		case s.walker.wantFile(path):
+			info, err := entry.Info()
+			entry = infoEntry{DirEntry: entry, info: info, err: err}
+		default:
+			continue
+		}
+		l.entries = append(l.entries, entry)
+	}
+
+	if len(children) > 0 && s.walker.workers > 0 {
+		// The pending listings are a stack, so that workers read ahead in
+		// the same depth-first order as the walk rather than wandering
+		// across the breadth of the tree
+		s.mutex.Lock()
+		for i := len(children) - 1; i >= 0; i-- {
+			s.pending = append(s.pending, children[i])
+		}
+		s.mutex.Unlock()
+		s.cond.Broadcast()
+	}
+}
+
+// work reads queued listings ahead of the walk until the walk ends.
+func (s *walkState) work() {
+	for {
+		s.mutex.Lock()
+		for len(s.pending) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if s.closed {
+			s.mutex.Unlock()
+			return
+		}
+		last := len(s.pending) - 1
+		l := s.pending[last]
+		s.pending[last] = nil
+		s.pending = s.pending[:last]
+		s.mutex.Unlock()
+
+		select {
+		case s.ahead <- struct{}{}:
+		case <-s.done:
+			return
+		case <-s.ctx.Done():
+			return
+		}
+
+		read := false
+		l.once.Do(func() {
+			read, l.ahead = true, true
+			s.list(l)
+		})
+		if !read {
+			// The walk reached the directory first
+			<-s.ahead
+		}
+	}
+}
+
+// stop ends the workers of a walk.
+func (s *walkState) stop() {
+	s.mutex.Lock()
+	s.closed = true
+	s.pending = nil
+	s.mutex.Unlock()
+	s.cond.Broadcast()
+	close(s.done)
+}
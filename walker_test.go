@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// deepTree creates a tree in dir that is depth levels deep, with fanout
+// subdirectories and a few files in each directory.
+func deepTree(tb testing.TB, dir string, depth, fanout int) {
+	tree := make(map[string]string)
+	var add func(folder string, depth int)
+	add = func(folder string, depth int) {
+		if depth == 0 {
+			tree[folder] = ""
+			return
+		}
+		for i := 0; i < 4; i++ {
+			tree[fmt.Sprintf("%sdrawing%d.dwg", folder, i)] = ""
+		}
+		for i := 0; i < fanout; i++ {
+			add(fmt.Sprintf("%sfolder%d/", folder, i), depth-1)
+		}
+	}
+	add("", depth)
+	writeTree(tb, dir, tree)
+}
+
+// slowReadDir simulates listing directories on a share with high latency.
+func slowReadDir(name string) ([]fs.DirEntry, error) {
+	time.Sleep(500 * time.Microsecond)
+	return os.ReadDir(name)
+}
+
+func TestTreeWalkerOrder(t *testing.T) {
+	dir := t.TempDir()
+	deepTree(t, dir, 4, 3)
+
+	// The walker must visit paths in the same order as filepath.Walk
+	var want []string
+	filepath.Walk(dir, func(path string, info fs.FileInfo, err error) error {
+		want = append(want, path)
+		return nil
+	})
+
+	tests := []struct {
+		name    string
+		workers int
+		readDir func(string) ([]fs.DirEntry, error)
+	}{
+		{name: "Sequential", workers: 0},
+		{name: "Parallel", workers: DefaultWalkers},
+		{name: "ParallelLatency", workers: DefaultWalkers, readDir: slowReadDir},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			walker := treeWalker{workers: tt.workers, readDir: tt.readDir}
+			err := walker.walk(context.Background(), dir, func(path string, d fs.DirEntry, err error) error {
+				got = append(got, path)
+				return err
+			})
+			if err != nil {
+				t.Fatalf("walk() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("the walker visited %d paths in a different order to the %d visited by filepath.Walk", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestTreeWalker(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"a.dwg":                  "",
+		"archive/old.dwg":        "",
+		"locked/secret.dwg":      "",
+		"notes.txt":              "",
+		"projects/1042/site.dwg": "",
+		"projects/skip/plan.dwg": "",
+		"z.dwg":                  "",
+	})
+	p := func(elem ...string) string {
+		return filepath.Join(append([]string{root}, elem...)...)
+	}
+
+	// The archive is excluded by the walker, the locked folder can't be
+	// listed, and the walk function skips the last folder itself. Files are
+	// marked with their size to show their info was read.
+	_, errLocked := lockedReadDir(p("locked"))
+	want := []string{
+		root,
+		p("a.dwg") + " (0 bytes)",
+		p("locked"),
+		p("locked") + ": " + errLocked.Error(),
+		p("projects"),
+		p("projects", "1042"),
+		p("projects", "1042", "site.dwg") + " (0 bytes)",
+		p("projects", "skip"),
+		p("z.dwg") + " (0 bytes)",
+	}
+
+	tests := []struct {
+		name    string
+		workers int
+		latency time.Duration
+	}{
+		{name: "Sequential", workers: 0},
+		{name: "Parallel", workers: DefaultWalkers},
+		{name: "ParallelLatency", workers: DefaultWalkers, latency: time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			listed := make(map[string]bool)
+			walker := treeWalker{
+				workers:  tt.workers,
+				skipDir:  func(path string) bool { return filepath.Base(path) == "archive" },
+				wantFile: func(path string) bool { return filepath.Ext(path) == ".dwg" },
+				readDir: func(name string) ([]fs.DirEntry, error) {
+					mutex.Lock()
+					listed[name] = true
+					mutex.Unlock()
+					time.Sleep(tt.latency)
+					return lockedReadDir(name)
+				},
+			}
+
+			var got []string
+			err := walker.walk(context.Background(), root, func(path string, d fs.DirEntry, err error) error {
+				switch {
+				case err != nil:
+					got = append(got, fmt.Sprintf("%s: %v", path, err))
+				case d.IsDir():
+					got = append(got, path)
+					if d.Name() == "skip" {
+						return filepath.SkipDir
+					}
+				default:
+					info, err := d.Info()
+					if err != nil {
+						t.Errorf("Info(%q) error = %v", path, err)
+						return nil
+					}
+					got = append(got, fmt.Sprintf("%s (%d bytes)", path, info.Size()))
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("walk() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("walk() visited:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+			// Workers may still be listing folders ahead of a walk that ended
+			mutex.Lock()
+			defer mutex.Unlock()
+			if listed[p("archive")] {
+				t.Errorf("walk() listed the excluded folder")
+			}
+		})
+	}
+}
+
+func BenchmarkWalk(b *testing.B) {
+	dir := b.TempDir()
+	deepTree(b, dir, 5, 4)
+
+	b.Run("filepath.Walk", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			filepath.Walk(dir, func(path string, info fs.FileInfo, err error) error { return nil })
+		}
+	})
+
+	for _, bench := range []struct {
+		name    string
+		workers int
+		readDir func(string) ([]fs.DirEntry, error)
+	}{
+		{name: "sequential", workers: 0},
+		{name: "parallel", workers: DefaultWalkers},
+		{name: "sequential-latency", workers: 0, readDir: slowReadDir},
+		{name: "parallel-latency", workers: DefaultWalkers, readDir: slowReadDir},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			walker := treeWalker{workers: bench.workers, readDir: bench.readDir}
+			for i := 0; i < b.N; i++ {
+				walker.walk(context.Background(), dir, func(path string, d fs.DirEntry, err error) error { return nil })
+			}
+		})
+	}
+}
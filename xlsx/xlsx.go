@@ -0,0 +1,362 @@
+// Package xlsx writes simple Excel workbooks in the Office Open XML format.
+//
+// It supports what's needed to export tables of results: text, numeric and
+// date cells, bold header rows that stay in view while scrolling, automatic
+// filters and column widths. It has no dependencies beyond the standard
+// library.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// cellKind identifies the type of a cell's value.
+type cellKind int
+
+const (
+	blankCell cellKind = iota
+	stringCell
+	numberCell
+	timeCell
+)
+
+// Cell is the value of a worksheet cell.
+type Cell struct {
+	kind cellKind
+	s    string
+	n    float64
+	t    time.Time
+}
+
+// String returns a text cell.
+func String(s string) Cell {
+	return Cell{kind: stringCell, s: s}
+}
+
+// Number returns a numeric cell.
+func Number(n float64) Cell {
+	return Cell{kind: numberCell, n: n}
+}
+
+// Int returns a numeric cell holding an integer.
+func Int(n int64) Cell {
+	return Number(float64(n))
+}
+
+// Time returns a date and time cell. The time is shown as it appears on the
+// clock in its location. A zero time gives a blank cell.
+func Time(t time.Time) Cell {
+	if t.IsZero() {
+		return Cell{}
+	}
+	return Cell{kind: timeCell, t: t}
+}
+
+// Blank returns an empty cell.
+func Blank() Cell {
+	return Cell{}
+}
+
+// Workbook is an Excel workbook made up of one or more worksheets.
+type Workbook struct {
+	sheets []*Sheet
+}
+
+// Sheet is a worksheet of a workbook.
+//
+// A sheet may start with a table: a header row followed by the rows added
+// before EndTable is called. The header row is shown in bold and frozen in
+// place, and the table is given an automatic filter.
+type Sheet struct {
+	name     string
+	rows     [][]Cell
+	widths   []float64
+	header   bool
+	tableEnd int // Number of rows in the table, including its header
+}
+
+// AddSheet adds an empty worksheet with the given name to the workbook.
+// Names should be unique, no longer than 31 characters and not contain any
+// of the characters []:*?/\.
+func (wb *Workbook) AddSheet(name string) *Sheet {
+	s := &Sheet{name: name}
+	wb.sheets = append(wb.sheets, s)
+	return s
+}
+
+// SetHeader adds a header row of text cells to the sheet, which must be
+// empty, starting its table.
+func (s *Sheet) SetHeader(titles ...string) {
+	row := make([]Cell, len(titles))
+	for i, title := range titles {
+		row[i] = String(title)
+	}
+	s.rows = append(s.rows, row)
+	s.header = true
+}
+
+// AddRow adds a row of cells to the sheet.
+func (s *Sheet) AddRow(cells ...Cell) {
+	s.rows = append(s.rows, cells)
+}
+
+// EndTable ends the sheet's table. Rows added afterwards are not included in
+// its filter. Tables that aren't ended include every row.
+func (s *Sheet) EndTable() {
+	s.tableEnd = len(s.rows)
+}
+
+// SetColumnWidths sets the widths of the sheet's first columns, measured in
+// characters.
+func (s *Sheet) SetColumnWidths(widths ...float64) {
+	s.widths = widths
+}
+
+// Write writes the workbook to w as an .xlsx file.
+func (wb *Workbook) Write(w io.Writer) error {
+	if len(wb.sheets) == 0 {
+		return fmt.Errorf("xlsx: workbook has no sheets")
+	}
+
+	z := zip.NewWriter(w)
+
+	parts := []struct {
+		name string
+		data []byte
+	}{
+		{"[Content_Types].xml", wb.contentTypes()},
+		{"_rels/.rels", []byte(rootRels)},
+		{"xl/workbook.xml", wb.workbook()},
+		{"xl/_rels/workbook.xml.rels", wb.workbookRels()},
+		{"xl/styles.xml", []byte(styles)},
+	}
+	for i, s := range wb.sheets {
+		parts = append(parts, struct {
+			name string
+			data []byte
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), s.xml()})
+	}
+
+	for _, part := range parts {
+		f, err := z.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(part.data); err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+const rootRels = xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// Cell styles, as indexes into the cellXfs of styles.
+const (
+	styleNormal = 0
+	styleHeader = 1
+	styleTime   = 2
+)
+
+const styles = xmlHeader + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="3">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
+
+func (wb *Workbook) contentTypes() []byte {
+	var b bytes.Buffer
+	b.WriteString(xmlHeader)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range wb.sheets {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+	return b.Bytes()
+}
+
+func (wb *Workbook) workbook() []byte {
+	var b bytes.Buffer
+	b.WriteString(xmlHeader)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, s := range wb.sheets {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(s.name), i+1, i+1)
+	}
+	b.WriteString(`</sheets>`)
+
+	// Excel expects each filter to have a matching hidden name
+	var names bytes.Buffer
+	for i, s := range wb.sheets {
+		if ref := s.filterRef(); ref != "" {
+			fmt.Fprintf(&names, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">'%s'!%s</definedName>`, i, escape(quoteSheetName(s.name)), absoluteRef(ref))
+		}
+	}
+	if names.Len() > 0 {
+		b.WriteString(`<definedNames>`)
+		b.Write(names.Bytes())
+		b.WriteString(`</definedNames>`)
+	}
+
+	b.WriteString(`</workbook>`)
+	return b.Bytes()
+}
+
+func (wb *Workbook) workbookRels() []byte {
+	var b bytes.Buffer
+	b.WriteString(xmlHeader)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range wb.sheets {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(wb.sheets)+1)
+	b.WriteString(`</Relationships>`)
+	return b.Bytes()
+}
+
+// filterRef returns the range covered by the sheet's filter, or an empty
+// string if the sheet has no table.
+func (s *Sheet) filterRef() string {
+	if !s.header || len(s.rows[0]) == 0 {
+		return ""
+	}
+	end := s.tableEnd
+	if end == 0 {
+		end = len(s.rows)
+	}
+	return CellRef(0, 0) + ":" + CellRef(len(s.rows[0])-1, end-1)
+}
+
+func (s *Sheet) xml() []byte {
+	var b bytes.Buffer
+	b.WriteString(xmlHeader)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+
+	if s.header {
+		b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	}
+
+	if len(s.widths) > 0 {
+		b.WriteString(`<cols>`)
+		for i, width := range s.widths {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%s" customWidth="1"/>`, i+1, i+1, strconv.FormatFloat(width, 'f', -1, 64))
+		}
+		b.WriteString(`</cols>`)
+	}
+
+	b.WriteString(`<sheetData>`)
+	for r, row := range s.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			style := styleNormal
+			if s.header && r == 0 {
+				style = styleHeader
+			}
+			writeCell(&b, CellRef(c, r), cell, style)
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData>`)
+
+	if ref := s.filterRef(); ref != "" {
+		fmt.Fprintf(&b, `<autoFilter ref="%s"/>`, ref)
+	}
+
+	b.WriteString(`</worksheet>`)
+	return b.Bytes()
+}
+
+func writeCell(b *bytes.Buffer, ref string, cell Cell, style int) {
+	styleAttr := ""
+	if cell.kind == timeCell {
+		style = styleTime
+	}
+	if style != styleNormal {
+		styleAttr = fmt.Sprintf(` s="%d"`, style)
+	}
+
+	switch cell.kind {
+	case stringCell:
+		fmt.Fprintf(b, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, styleAttr, escape(cell.s))
+	case numberCell:
+		fmt.Fprintf(b, `<c r="%s"%s><v>%s</v></c>`, ref, styleAttr, strconv.FormatFloat(cell.n, 'g', -1, 64))
+	case timeCell:
+		fmt.Fprintf(b, `<c r="%s"%s><v>%s</v></c>`, ref, styleAttr, strconv.FormatFloat(serialDate(cell.t), 'f', -1, 64))
+	}
+}
+
+// serialDate returns t as an Excel serial date: the number of days since the
+// end of 1899, with the time of day as a fraction.
+func serialDate(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	return wall.Sub(epoch).Hours() / 24
+}
+
+// CellRef returns the A1-style reference of the cell in the given
+// zero-based column and row.
+func CellRef(col, row int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name + strconv.Itoa(row+1)
+}
+
+// absoluteRef returns a range such as A1:C5 as $A$1:$C$5.
+func absoluteRef(ref string) string {
+	var b bytes.Buffer
+	letters := false
+	for _, r := range ref {
+		isLetter := r >= 'A' && r <= 'Z'
+		if isLetter && !letters || !isLetter && letters && r != ':' {
+			b.WriteByte('$')
+		}
+		letters = isLetter
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// quoteSheetName doubles any single quotes in a sheet name, so that it can
+// be quoted in a formula.
+func quoteSheetName(name string) string {
+	var b bytes.Buffer
+	for _, r := range name {
+		if r == '\'' {
+			b.WriteRune(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// escape returns s with XML special characters escaped. Characters that XML
+// can't represent are replaced.
+func escape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,148 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCellRef(t *testing.T) {
+	tests := []struct {
+		col, row int
+		want     string
+	}{
+		{0, 0, "A1"},
+		{25, 9, "Z10"},
+		{26, 0, "AA1"},
+		{51, 0, "AZ1"},
+		{52, 0, "BA1"},
+		{701, 0, "ZZ1"},
+		{702, 99, "AAA100"},
+	}
+
+	for _, tt := range tests {
+		if got := CellRef(tt.col, tt.row); got != tt.want {
+			t.Errorf("CellRef(%d, %d) = %s, want %s", tt.col, tt.row, got, tt.want)
+		}
+	}
+}
+
+func TestSerialDate(t *testing.T) {
+	tests := []struct {
+		t    time.Time
+		want float64
+	}{
+		{time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC), 1},
+		{time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), 36526},
+		{time.Date(2000, 1, 1, 18, 0, 0, 0, time.UTC), 36526.75},
+		{time.Date(2000, 1, 1, 18, 0, 0, 0, time.FixedZone("MST", -7*60*60)), 36526.75},
+	}
+
+	for _, tt := range tests {
+		if got := serialDate(tt.t); got != tt.want {
+			t.Errorf("serialDate(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestAbsoluteRef(t *testing.T) {
+	if got, want := absoluteRef("A1:AB12"), "$A$1:$AB$12"; got != want {
+		t.Errorf("absoluteRef() = %s, want %s", got, want)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var wb Workbook
+	files := wb.AddSheet("Files")
+	files.SetHeader("Path", "Size", "Modified")
+	files.AddRow(String(`C:\Projects\A & B <1>.dwg`), Int(1024), Time(time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)))
+	files.AddRow(String("old.dwg"), Number(1.5), Time(time.Time{}))
+	files.SetColumnWidths(60, 12, 20)
+
+	summary := wb.AddSheet("Summary")
+	summary.SetHeader("Version", "Drawings")
+	summary.AddRow(String("AC1032"), Int(2))
+	summary.EndTable()
+	summary.AddRow()
+	summary.AddRow(String("Root"), String(`C:\Projects`))
+
+	var buf bytes.Buffer
+	if err := wb.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+
+	parts := make(map[string]string)
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", f.Name, err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("ReadAll(%s) error = %v", f.Name, err)
+		}
+
+		// Every part must be well-formed XML
+		d := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			if _, err := d.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed: %v", f.Name, err)
+			}
+		}
+
+		parts[f.Name] = string(data)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("workbook is missing %s", name)
+		}
+	}
+
+	contains := []struct {
+		part string
+		text string
+	}{
+		{"xl/workbook.xml", `<sheet name="Files" sheetId="1" r:id="rId1"/>`},
+		{"xl/workbook.xml", `localSheetId="0" hidden="1">'Files'!$A$1:$C$3</definedName>`},
+		{"xl/workbook.xml", `localSheetId="1" hidden="1">'Summary'!$A$1:$B$2</definedName>`},
+		{"xl/worksheets/sheet1.xml", `<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`},
+		{"xl/worksheets/sheet1.xml", `<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">Path</t></is></c>`},
+		{"xl/worksheets/sheet1.xml", `<t xml:space="preserve">C:\Projects\A &amp; B &lt;1&gt;.dwg</t>`},
+		{"xl/worksheets/sheet1.xml", `<c r="B2"><v>1024</v></c>`},
+		{"xl/worksheets/sheet1.xml", `<c r="C2" s="2"><v>45047.5</v></c>`},
+		{"xl/worksheets/sheet1.xml", `<c r="B3"><v>1.5</v></c>`},
+		{"xl/worksheets/sheet1.xml", `<col min="1" max="1" width="60" customWidth="1"/>`},
+		{"xl/worksheets/sheet1.xml", `<autoFilter ref="A1:C3"/>`},
+		{"xl/worksheets/sheet2.xml", `<autoFilter ref="A1:B2"/>`},
+		{"xl/worksheets/sheet2.xml", `<row r="3"></row>`},
+	}
+	for _, c := range contains {
+		if !strings.Contains(parts[c.part], c.text) {
+			t.Errorf("%s does not contain %s", c.part, c.text)
+		}
+	}
+
+	if strings.Contains(parts["xl/worksheets/sheet1.xml"], `r="C3"`) {
+		t.Errorf("zero time was written as a cell")
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	var wb Workbook
+	if err := wb.Write(io.Discard); err == nil {
+		t.Fatalf("Write() of a workbook without sheets succeeded")
+	}
+}
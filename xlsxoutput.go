@@ -0,0 +1,183 @@
+package main
+
+import (
+	"io"
+	"sort"
+
+	"github.com/scjalliance/cadscan/xlsx"
+)
+
+// xlsxWriter writes the results of its scans as an Excel workbook with
+// Files, Summary and Errors sheets.
+//
+// A workbook can only be written once it is complete, so xlsxWriter buffers
+// the results until Flush is called.
+type xlsxWriter struct {
+	w         io.Writer
+	summaries []Summary
+	errors    []string
+	files     []File
+}
+
+func newXLSXWriter(w io.Writer) *xlsxWriter {
+	return &xlsxWriter{w: w}
+}
+
+// Start has no effect. The roots are recorded by Finish.
+func (w *xlsxWriter) Start(roots []string) {}
+
+// Append adds the given results to the workbook.
+func (w *xlsxWriter) Append(results ...File) {
+	w.files = append(w.files, results...)
+}
+
+// Error records err in the workbook.
+func (w *xlsxWriter) Error(err error) {
+	w.errors = append(w.errors, err.Error())
+}
+
+// Finish adds the summary of a scan to the workbook.
+func (w *xlsxWriter) Finish(summary Summary) {
+	w.summaries = append(w.summaries, summary)
+}
+
+// xlsxFileColumns are the titles of the typed columns that start the Files
+// sheet. The remaining result columns follow them as text.
+var xlsxFileColumns = []string{"File", "Root", "Format", "Header", "Version", "Release", "Size", "Modified"}
+
+// Flush writes the workbook.
+func (w *xlsxWriter) Flush() error {
+	var wb xlsx.Workbook
+	w.filesSheet(wb.AddSheet("Files"))
+	w.summarySheet(wb.AddSheet("Summary"))
+	w.errorsSheet(wb.AddSheet("Errors"))
+	return wb.Write(w.w)
+}
+
+// filesSheet fills sheet with a row for each result.
+func (w *xlsxWriter) filesSheet(sheet *xlsx.Sheet) {
+	typed := make(map[string]bool)
+	for _, title := range xlsxFileColumns {
+		typed[title] = true
+	}
+	var extra []column
+	for _, c := range resultColumns {
+		if !typed[c.Title] {
+			extra = append(extra, c)
+		}
+	}
+
+	sheet.SetHeader(append(append([]string(nil), xlsxFileColumns...), columnTitles(extra)...)...)
+
+	widths := []float64{80, 40, 10, 10, 28, 9, 12, 20}
+	for range extra {
+		widths = append(widths, 16)
+	}
+	sheet.SetColumnWidths(widths...)
+
+	for _, file := range w.files {
+		release := xlsx.Blank()
+		if r := file.Version.Release(); r > 0 {
+			release = xlsx.Int(int64(r))
+		}
+		size := xlsx.Blank()
+		if !file.ModTime.IsZero() {
+			// Failures found while walking the file system have no size
+			size = xlsx.Int(file.Size)
+		}
+
+		row := []xlsx.Cell{
+			xlsx.String(file.Path),
+			xlsx.String(file.Root),
+			xlsx.String(file.Format.String()),
+			xlsx.String(file.Version.String()),
+			xlsx.String(file.Version.ReleaseName()),
+			release,
+			size,
+			xlsx.Time(file.ModTime),
+		}
+		for _, value := range columnValues(extra, file) {
+			row = append(row, xlsx.String(value))
+		}
+		sheet.AddRow(row...)
+	}
+}
+
+// summarySheet fills sheet with the number and size of the drawings of each
+// version, followed by the details of each scan.
+func (w *xlsxWriter) summarySheet(sheet *xlsx.Sheet) {
+	type totals struct {
+		drawings int
+		bytes    int64
+	}
+	versions := make(map[DrawingVersion]*totals)
+	for _, file := range w.files {
+		if file.Failed() {
+			continue
+		}
+		t := versions[file.Version]
+		if t == nil {
+			t = &totals{}
+			versions[file.Version] = t
+		}
+		t.drawings++
+		t.bytes += file.Size
+	}
+
+	sorted := make([]DrawingVersion, 0, len(versions))
+	for v := range versions {
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Release() < sorted[j].Release()
+	})
+
+	sheet.SetHeader("Header", "Version", "Release", "Drawings", "Bytes")
+	sheet.SetColumnWidths(16, 28, 20, 20, 14)
+	for _, v := range sorted {
+		t := versions[v]
+		sheet.AddRow(xlsx.String(v.String()), xlsx.String(v.ReleaseName()), xlsx.Int(int64(v.Release())), xlsx.Int(int64(t.drawings)), xlsx.Int(t.bytes))
+	}
+	sheet.EndTable()
+
+	for _, summary := range w.summaries {
+		sheet.AddRow()
+		sheet.AddRow(xlsx.String("Roots"), xlsx.String(joinRoots(summary.Roots)))
+		sheet.AddRow(xlsx.String("Started"), xlsx.Time(summary.Started))
+		sheet.AddRow(xlsx.String("Finished"), xlsx.Time(summary.Finished))
+		sheet.AddRow(xlsx.String("Workers"), xlsx.Int(int64(summary.Workers)))
+		sheet.AddRow(xlsx.String("Files"), xlsx.Int(int64(summary.Files)))
+		sheet.AddRow(xlsx.String("Drawings read"), xlsx.Int(int64(summary.Drawings)))
+		sheet.AddRow(xlsx.String("Could not be read"), xlsx.Int(int64(summary.Failed())))
+		sheet.AddRow(xlsx.String("From cache"), xlsx.Int(int64(summary.CacheHits)))
+		if len(summary.Roots) < 2 {
+			continue
+		}
+		sheet.AddRow()
+		sheet.AddRow(xlsx.String("Root"), xlsx.String("Files"), xlsx.String("Drawings read"), xlsx.String("Could not be read"), xlsx.String("From cache"))
+		for i, root := range summary.Roots {
+			t := summary.PerRoot[i]
+			sheet.AddRow(xlsx.String(root), xlsx.Int(int64(t.Files)), xlsx.Int(int64(t.Drawings)), xlsx.Int(int64(t.Failed())), xlsx.Int(int64(t.CacheHits)))
+		}
+	}
+}
+
+// errorsSheet fills sheet with the files that could not be assessed and the
+// errors that stopped scans.
+func (w *xlsxWriter) errorsSheet(sheet *xlsx.Sheet) {
+	sheet.SetHeader("File", "Reason", "Error")
+	sheet.SetColumnWidths(80, 20, 60)
+	for _, file := range w.files {
+		if !file.Failed() {
+			continue
+		}
+		message := ""
+		if file.Err != nil {
+			message = file.Err.Error()
+		}
+		sheet.AddRow(xlsx.String(file.Path), xlsx.String(file.Reason.String()), xlsx.String(message))
+	}
+	for _, err := range w.errors {
+		sheet.AddRow(xlsx.Blank(), xlsx.String("scan stopped"), xlsx.String(err))
+	}
+}
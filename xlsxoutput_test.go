@@ -0,0 +1,119 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scjalliance/cadscan/dwg"
+	"github.com/scjalliance/cadscan/xlsx"
+)
+
+func TestXLSXWriter(t *testing.T) {
+	root := filepath.Join("srv", "projects")
+	modified := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	files := []File{
+		{Path: filepath.Join(root, "a.dwg"), Root: root, Size: 2048, ModTime: modified, Format: FormatDWG, Version: "AC1032"},
+		{Path: filepath.Join(root, "b.dwg"), Root: root, Size: 1024, ModTime: modified, Format: FormatDWG, Version: "AC1015"},
+		{Path: filepath.Join(root, "c.dwg"), Root: root, Size: 512, ModTime: modified, Format: FormatDWG, Version: "AC1032"},
+		{Path: filepath.Join(root, "bad.dwg"), Root: root, Size: 10, ModTime: modified, Format: FormatDWG, Reason: ReasonTruncated, Err: dwg.ErrTruncated},
+		{Path: filepath.Join(root, "locked"), Root: root, Dir: true, Reason: ReasonPermission, Err: &fs.PathError{Op: "open", Path: filepath.Join(root, "locked"), Err: fs.ErrPermission}},
+	}
+
+	var buf bytes.Buffer
+	w := newXLSXWriter(&buf)
+	w.Start([]string{root})
+	w.Append(files...)
+	w.Error(errors.New("export interrupted"))
+	w.Finish(Summary{Roots: []string{root}, Workers: 2, Started: modified, Finished: modified.Add(time.Minute)})
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	parts := make(map[string]string)
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", f.Name, err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("ReadAll(%s) error = %v", f.Name, err)
+		}
+		parts[f.Name] = string(data)
+	}
+	filesSheet, summarySheet, errorsSheet := parts["xl/worksheets/sheet1.xml"], parts["xl/worksheets/sheet2.xml"], parts["xl/worksheets/sheet3.xml"]
+
+	// The Files sheet has the typed columns followed by the other result
+	// columns as text
+	typed := make(map[string]bool)
+	for _, title := range xlsxFileColumns {
+		typed[title] = true
+	}
+	columns := len(xlsxFileColumns)
+	for _, c := range resultColumns {
+		if !typed[c.Title] {
+			columns++
+		}
+	}
+	str := func(ref, s string) string {
+		return fmt.Sprintf(`<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, s)
+	}
+	num := func(ref string, n int64) string {
+		return fmt.Sprintf(`<c r="%s"><v>%d</v></c>`, ref, n)
+	}
+
+	contains := []struct {
+		name string
+		part string
+		text string
+	}{
+		{"Files", filesSheet, str("A2", filepath.Join(root, "a.dwg"))},
+		{"Files", filesSheet, num("F2", int64(DrawingVersion("AC1032").Release()))},
+		{"Files", filesSheet, num("G2", 2048)},
+		{"Files", filesSheet, `<c r="H2" s="2"><v>45047.5</v></c>`},
+		{"Files", filesSheet, num("F3", int64(DrawingVersion("AC1015").Release()))},
+		{"Files", filesSheet, num("G5", 10)},
+		{"Files", filesSheet, fmt.Sprintf(`<autoFilter ref="A1:%s"/>`, xlsx.CellRef(columns-1, len(files)))},
+
+		{"Summary", summarySheet, str("A2", "AC1015") + str("B2", DrawingVersion("AC1015").ReleaseName())},
+		{"Summary", summarySheet, num("D2", 1) + num("E2", 1024)},
+		{"Summary", summarySheet, str("A3", "AC1032") + str("B3", DrawingVersion("AC1032").ReleaseName())},
+		{"Summary", summarySheet, num("D3", 2) + num("E3", 2560)},
+		{"Summary", summarySheet, `<row r="4"></row>`},
+		{"Summary", summarySheet, `<autoFilter ref="A1:E3"/>`},
+
+		{"Errors", errorsSheet, str("A2", filepath.Join(root, "bad.dwg")) + str("B2", "truncated header") + str("C2", dwg.ErrTruncated.Error())},
+		{"Errors", errorsSheet, str("A3", filepath.Join(root, "locked")) + str("B3", "permission denied")},
+		{"Errors", errorsSheet, `<row r="4">` + str("B4", "scan stopped") + str("C4", "export interrupted") + `</row>`},
+		{"Errors", errorsSheet, `<autoFilter ref="A1:C4"/>`},
+
+		{"workbook", parts["xl/workbook.xml"], `localSheetId="0" hidden="1">'Files'!$A$1:$`},
+		{"workbook", parts["xl/workbook.xml"], `localSheetId="1" hidden="1">'Summary'!$A$1:$E$3<`},
+		{"workbook", parts["xl/workbook.xml"], `localSheetId="2" hidden="1">'Errors'!$A$1:$C$4<`},
+	}
+	for _, c := range contains {
+		if !strings.Contains(c.part, c.text) {
+			t.Errorf("the %s sheet doesn't contain %s", c.name, c.text)
+		}
+	}
+
+	// Results without a version or a size leave those cells blank
+	for _, ref := range []string{"F5", "G6", "H6"} {
+		if strings.Contains(filesSheet, fmt.Sprintf(`<c r="%s"`, ref)) {
+			t.Errorf("the Files sheet has a %s cell, want it blank", ref)
+		}
+	}
+}